@@ -0,0 +1,512 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+func (app *application) adminDashboard(w http.ResponseWriter, r *http.Request) {
+	userStats, err := app.users.Stats()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	postStats, err := app.posts.Stats()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.UserStats = userStats
+	data.PostStats = postStats
+	app.renderTemplate(w, r, http.StatusOK, "admin.html", data)
+}
+
+// adminBackup downloads the same archive as the backup subcommand, without
+// password hashes.
+func (app *application) adminBackup(w http.ResponseWriter, r *http.Request) {
+	buf := new(bytes.Buffer)
+
+	err := app.writeBackup(buf, false)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditBackup}, nil, nil)
+
+	filename := fmt.Sprintf("microblog-%s.zip", time.Now().UTC().Format("20060102-150405"))
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	buf.WriteTo(w)
+}
+
+func (app *application) adminUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+
+	users, err := app.users.Search(query)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Query = query
+	data.Users = users
+	app.renderTemplate(w, r, http.StatusOK, "admin_users.html", data)
+}
+
+func (app *application) adminUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.adminUserFromParams(w, r)
+	if !ok {
+		return
+	}
+
+	app.renderAdminUser(w, r, http.StatusOK, user, &adminPasswordForm{})
+}
+
+func (app *application) renderAdminUser(w http.ResponseWriter, r *http.Request, status int, user *models.User, form *adminPasswordForm) {
+	data := app.newTemplateData(r)
+	data.Form = form
+	data.Roles = models.Roles()
+	data.User = user
+	app.renderTemplate(w, r, status, "admin_user.html", data)
+}
+
+// adminUserFromParams loads the user named by the :id parameter, responding
+// with 404 Not Found if there isn't one.
+func (app *application) adminUserFromParams(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return nil, false
+	}
+
+	user, err := app.users.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (app *application) adminUserDisablePost(w http.ResponseWriter, r *http.Request) {
+	app.adminSetDisabled(w, r, true)
+}
+
+func (app *application) adminUserEnablePost(w http.ResponseWriter, r *http.Request) {
+	app.adminSetDisabled(w, r, false)
+}
+
+func (app *application) adminSetDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	user, ok := app.adminUserFromParams(w, r)
+	if !ok {
+		return
+	}
+
+	// Admins can't lock themselves out.
+	if user.Id == app.authenticatedUserID(r) {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	err := app.users.SetDisabled(user.Id, disabled)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	action := models.AuditUserEnable
+	if disabled {
+		action = models.AuditUserDisable
+	}
+
+	app.audit(r, &models.AuditEvent{Action: action, TargetType: "user", TargetId: user.Id},
+		map[string]bool{"disabled": user.Disabled}, map[string]bool{"disabled": disabled})
+
+	// Flashes are translated when they're shown, but only the format of
+	// those with arguments is in the catalogs, so they're translated here.
+	if disabled {
+		app.sessionManager.Put(r.Context(), "flash", app.localizer(r).T("Disabled %s", user.Username))
+	} else {
+		app.sessionManager.Put(r.Context(), "flash", app.localizer(r).T("Enabled %s", user.Username))
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/admin/users/%d", user.Id), http.StatusSeeOther)
+}
+
+func (app *application) adminUserRolePost(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.adminUserFromParams(w, r)
+	if !ok {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	role := r.PostForm.Get("role")
+
+	if user.Id == app.authenticatedUserID(r) && role != user.Role {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	err = app.users.SetRole(user.Id, role)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidRole) {
+			app.clientError(w, r, http.StatusBadRequest)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditUserRole, TargetType: "user", TargetId: user.Id},
+		map[string]string{"role": user.Role}, map[string]string{"role": role})
+
+	app.sessionManager.Put(r.Context(), "flash", app.localizer(r).T("%s is now %s", user.Username, role))
+
+	http.Redirect(w, r, fmt.Sprintf("/admin/users/%d", user.Id), http.StatusSeeOther)
+}
+
+func (app *application) adminUserPasswordPost(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.adminUserFromParams(w, r)
+	if !ok {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	form := &adminPasswordForm{
+		Password: r.PostForm.Get("password"),
+	}
+
+	if !form.Validate() {
+		app.renderAdminUser(w, r, http.StatusUnprocessableEntity, user, form)
+		return
+	}
+
+	err = app.users.SetPassword(user.Id, form.Password)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditPasswordChange, TargetType: "user", TargetId: user.Id}, nil, nil)
+
+	app.sessionManager.Put(r.Context(), "flash", app.localizer(r).T("Password of %s reset", user.Username))
+
+	http.Redirect(w, r, fmt.Sprintf("/admin/users/%d", user.Id), http.StatusSeeOther)
+}
+
+func (app *application) adminPosts(w http.ResponseWriter, r *http.Request) {
+	form := &adminPostsForm{
+		Status: r.URL.Query().Get("status"),
+	}
+
+	app.renderAdminPosts(w, r, http.StatusOK, form)
+}
+
+func (app *application) renderAdminPosts(w http.ResponseWriter, r *http.Request, status int, form *adminPostsForm) {
+	posts, err := app.posts.List(form.Status)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Form = form
+	data.Posts = posts
+	app.renderTemplate(w, r, status, "admin_posts.html", data)
+}
+
+func (app *application) adminPostsPost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	form := &adminPostsForm{
+		Status: r.PostForm.Get("status"),
+		Action: r.PostForm.Get("action"),
+		Author: r.PostForm.Get("author"),
+	}
+
+	for _, value := range r.PostForm["id"] {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			app.clientError(w, r, http.StatusBadRequest)
+			return
+		}
+		form.Ids = append(form.Ids, id)
+	}
+
+	if !form.Validate() {
+		app.renderAdminPosts(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	var n int
+
+	switch form.Action {
+	case "publish", "unpublish":
+		action := models.ReviewPublish
+		if form.Action == "unpublish" {
+			action = models.ReviewUnpublish
+		}
+		for _, id := range form.Ids {
+			_, err = app.posts.Transition(id, app.authenticatedUserID(r), action, "")
+			if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrInvalidTransition) {
+				err = nil
+				continue
+			}
+			if err != nil {
+				break
+			}
+			if action == models.ReviewPublish {
+				app.notifyMentions(r, id)
+			}
+			n++
+		}
+	case "delete":
+		for _, id := range form.Ids {
+			err = app.posts.Delete(id)
+			if errors.Is(err, models.ErrNoRecord) {
+				err = nil
+				continue
+			}
+			if err != nil {
+				break
+			}
+			n++
+		}
+	case "reassign":
+		var author *models.User
+		author, err = app.users.GetByUsername(form.Author)
+		if errors.Is(err, models.ErrNoRecord) {
+			form.AddFieldError("author", "No such user")
+			app.renderAdminPosts(w, r, http.StatusUnprocessableEntity, form)
+			return
+		}
+		if err == nil {
+			n, err = app.posts.Reassign(form.Ids, author.Id)
+		}
+	}
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditPostsBulk, TargetType: "post"}, nil, map[string]any{
+		"action":  form.Action,
+		"ids":     form.Ids,
+		"author":  form.Author,
+		"changed": n,
+	})
+
+	app.sessionManager.Put(r.Context(), "flash", app.localizer(r).T("Updated %d of %d posts", n, len(form.Ids)))
+
+	http.Redirect(w, r, "/admin/posts?status="+url.QueryEscape(form.Status), http.StatusSeeOther)
+}
+
+// auditPageSize is how many events the audit log page shows; the JSON
+// export has no limit.
+const auditPageSize = 200
+
+func (app *application) adminAudit(w http.ResponseWriter, r *http.Request) {
+	form := newAuditFilterForm(r)
+
+	data := app.newTemplateData(r)
+	data.AuditActions = models.AuditActions()
+	data.Form = form
+
+	if !form.Validate() {
+		app.renderTemplate(w, r, http.StatusUnprocessableEntity, "admin_audit.html", data)
+		return
+	}
+
+	events, err := app.auditLog.List(form.Filter(auditPageSize))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data.AuditEvents = events
+	app.renderTemplate(w, r, http.StatusOK, "admin_audit.html", data)
+}
+
+// adminAuditExport downloads the events matching the same filters as the
+// audit log page as a JSON array.
+func (app *application) adminAuditExport(w http.ResponseWriter, r *http.Request) {
+	form := newAuditFilterForm(r)
+
+	if !form.Validate() {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	events, err := app.auditLog.List(form.Filter(0))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
+
+	err = enc.Encode(events)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.json"`)
+
+	buf.WriteTo(w)
+}
+
+func newAuditFilterForm(r *http.Request) *auditFilterForm {
+	query := r.URL.Query()
+
+	return &auditFilterForm{
+		Action: query.Get("action"),
+		Actor:  query.Get("actor"),
+		Since:  query.Get("since"),
+		Until:  query.Get("until"),
+	}
+}
+
+// adminFlagged lists the posts held back by the spam filter.
+func (app *application) adminFlagged(w http.ResponseWriter, r *http.Request) {
+	posts, err := app.posts.List(models.PostStatusFlagged)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Posts = posts
+	app.renderTemplate(w, r, http.StatusOK, "admin_flagged.html", data)
+}
+
+// adminFlaggedApprovePost publishes a flagged post, or sends it to the
+// review queue if its author's posts need review.
+func (app *application) adminFlaggedApprovePost(w http.ResponseWriter, r *http.Request) {
+	post, ok := app.postFromParams(w, r, models.PostStatusFlagged)
+	if !ok {
+		return
+	}
+
+	author, err := app.users.Get(post.UserId)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		app.serverError(w, r, err)
+		return
+	}
+
+	action := models.ReviewRelease
+	if app.authorNeedsReview(author) {
+		action = models.ReviewReleaseToReview
+	}
+
+	review, err := app.posts.Transition(post.Id, app.authenticatedUserID(r), action, "")
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidTransition) {
+			app.clientError(w, r, http.StatusConflict)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditPostApprove, TargetType: "post", TargetId: post.Id},
+		nil, &reviewAudit{Action: review.Action, From: review.From, To: review.To})
+
+	if review.To == models.PostStatusPublished {
+		app.notifyMentions(r, post.Id)
+		app.sessionManager.Put(r.Context(), "flash", "Post approved and published")
+	} else {
+		app.sessionManager.Put(r.Context(), "flash", "Post released and submitted for review")
+	}
+
+	http.Redirect(w, r, "/admin/flagged", http.StatusSeeOther)
+}
+
+// adminFlaggedRejectPost moves a flagged post to the trash.
+func (app *application) adminFlaggedRejectPost(w http.ResponseWriter, r *http.Request) {
+	post, ok := app.postFromParams(w, r, models.PostStatusFlagged)
+	if !ok {
+		return
+	}
+
+	err := app.posts.Delete(post.Id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditPostReject, TargetType: "post", TargetId: post.Id}, newPostAudit(post), nil)
+
+	app.sessionManager.Put(r.Context(), "flash", "Post rejected and moved to trash")
+
+	http.Redirect(w, r, "/admin/flagged", http.StatusSeeOther)
+}
+
+// postFromParams loads the post named by the :id parameter, responding
+// with 404 Not Found unless it has the given status.
+func (app *application) postFromParams(w http.ResponseWriter, r *http.Request, status string) (*models.Post, bool) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return nil, false
+	}
+
+	post, err := app.posts.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return nil, false
+	}
+
+	if post.Status != status {
+		app.notFound(w, r)
+		return nil, false
+	}
+
+	return post, true
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// loginAdmin logs in as a new user with the admin role.
+func (ts *testServer) loginAdmin(t *testing.T, app *application, username string) *models.User {
+	t.Helper()
+
+	ts.login(t, app, username, "pa$$word")
+
+	user, err := app.users.GetByUsername(username)
+	assert.NilError(t, err)
+	assert.NilError(t, app.users.SetRole(user.Id, models.RoleAdmin))
+
+	return user
+}
+
+func TestAdminRequiresPermission(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	code, header, _ := ts.get(t, "/admin")
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/user/login")
+
+	ts.login(t, app, "alice", "pa$$word")
+
+	code, _, _ = ts.get(t, "/admin")
+	assert.Equal(t, code, http.StatusForbidden)
+
+	_, _, body := ts.get(t, "/")
+	assert.Equal(t, strings.Contains(body, `href="/admin"`), false)
+
+	admin := newTestServer(t, app.routes())
+	admin.loginAdmin(t, app, "carol")
+
+	code, _, body = admin.get(t, "/admin")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "Disabled accounts")
+
+	_, _, body = admin.get(t, "/")
+	assert.StringContains(t, body, `<a href="/admin">Admin</a>`)
+}
+
+func TestAdminManageUser(t *testing.T) {
+	app := newTestApplication(t)
+
+	user := newTestServer(t, app.routes())
+	user.login(t, app, "alice", "pa$$word")
+
+	alice, err := app.users.GetByUsername("alice")
+	assert.NilError(t, err)
+
+	admin := newTestServer(t, app.routes())
+	carol := admin.loginAdmin(t, app, "carol")
+
+	_, _, body := admin.get(t, "/admin/users?q=ali")
+	assert.StringContains(t, body, "alice@example.com")
+
+	csrf := url.Values{}
+	csrf.Add("csrf_token", admin.csrfToken(t, "/admin/users/1"))
+
+	code, _, _ := admin.postForm(t, "/admin/users/1/disable", csrf)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	code, _, _ = admin.postForm(t, fmt.Sprintf("/admin/users/%d/disable", carol.Id), csrf)
+	assert.Equal(t, code, http.StatusBadRequest)
+
+	code, header, _ := user.get(t, "/post/add")
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/user/login")
+
+	form := url.Values{}
+	form.Add("username", "alice")
+	form.Add("password", "pa$$word")
+	form.Add("csrf_token", user.csrfToken(t, "/user/login"))
+
+	code, _, body = user.postForm(t, "/user/login", form)
+	assert.Equal(t, code, http.StatusForbidden)
+	assert.StringContains(t, body, "This account has been disabled")
+
+	code, _, _ = admin.postForm(t, "/admin/users/1/enable", csrf)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	reset := url.Values{}
+	reset.Add("password", "short")
+	reset.Add("csrf_token", csrf.Get("csrf_token"))
+
+	code, _, body = admin.postForm(t, "/admin/users/1/password", reset)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "atleast 8 characters")
+
+	reset.Set("password", "new pa$$word")
+	code, _, _ = admin.postForm(t, "/admin/users/1/password", reset)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	id, err := app.users.Authenticate("alice", "new pa$$word")
+	assert.NilError(t, err)
+	assert.Equal(t, id, alice.Id)
+
+	role := url.Values{}
+	role.Add("role", "owner")
+	role.Add("csrf_token", csrf.Get("csrf_token"))
+
+	code, _, _ = admin.postForm(t, "/admin/users/1/role", role)
+	assert.Equal(t, code, http.StatusBadRequest)
+
+	role.Set("role", models.RoleAdmin)
+	code, _, _ = admin.postForm(t, "/admin/users/1/role", role)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	alice, err = app.users.Get(alice.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, alice.Role, models.RoleAdmin)
+}
+
+func TestAdminBulkPosts(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	err := app.users.Insert("alice", "alice@example.com", "pa$$word")
+	assert.NilError(t, err)
+
+	for _, title := range []string{"First post", "Second post"} {
+		newPost(t, app, 1, title, "Content", models.PostStatusPublished, models.PostVisibilityPublic)
+	}
+
+	carol := ts.loginAdmin(t, app, "carol")
+
+	form := url.Values{}
+	form.Add("action", "unpublish")
+	form.Add("csrf_token", ts.csrfToken(t, "/admin/posts"))
+
+	code, _, body := ts.postForm(t, "/admin/posts", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "Select at least one post")
+
+	form.Add("id", "1")
+	code, _, _ = ts.postForm(t, "/admin/posts", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, "/")
+	assert.Equal(t, strings.Contains(body, "First post"), false)
+	assert.StringContains(t, body, "Second post")
+
+	// Drafts are only visible to their author and admins.
+	code, _, _ = ts.get(t, "/post/view/1")
+	assert.Equal(t, code, http.StatusOK)
+
+	reviews, err := app.posts.Reviews(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(reviews), 1)
+	assert.Equal(t, reviews[0].Action, models.ReviewUnpublish)
+	assert.Equal(t, reviews[0].ActorId, carol.Id)
+
+	anonymous := newTestServer(t, app.routes())
+	code, _, _ = anonymous.get(t, "/post/view/1")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	form.Set("action", "reassign")
+	form.Set("author", "nobody")
+	code, _, body = ts.postForm(t, "/admin/posts", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "No such user")
+
+	form.Set("author", "carol")
+	form.Add("id", "2")
+	code, _, _ = ts.postForm(t, "/admin/posts", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	posts, err := app.posts.GetByUser(carol.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(posts), 2)
+
+	form.Set("action", "delete")
+	code, _, _ = ts.postForm(t, "/admin/posts", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	stats, err := app.posts.Stats()
+	assert.NilError(t, err)
+	assert.Equal(t, stats.Trashed, 2)
+}
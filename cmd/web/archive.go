@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+// archiveYear is a year of the archive with the months in it that have
+// posts, newest first.
+type archiveYear struct {
+	Year   int
+	Posts  int
+	Months []*models.ArchiveMonth
+}
+
+// archive groups the post counts per month by year.
+func (app *application) archive() ([]*archiveYear, error) {
+	months, err := app.posts.Archive()
+	if err != nil {
+		return nil, err
+	}
+
+	years := []*archiveYear{}
+
+	for _, month := range months {
+		if len(years) == 0 || years[len(years)-1].Year != month.Year {
+			years = append(years, &archiveYear{Year: month.Year})
+		}
+
+		year := years[len(years)-1]
+		year.Posts += month.Posts
+		year.Months = append(year.Months, month)
+	}
+
+	return years, nil
+}
+
+func (app *application) archiveIndex(w http.ResponseWriter, r *http.Request) {
+	years, err := app.archive()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Archive = years
+	app.renderTemplate(w, r, http.StatusOK, "archive.html", data)
+}
+
+// archiveYearView lists the posts of a year. Years without posts don't
+// exist.
+func (app *application) archiveYearView(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	year, err := strconv.Atoi(params.ByName("year"))
+	if err != nil || year < 1 || year > 9999 {
+		app.notFound(w, r)
+		return
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	app.renderArchivePeriod(w, r, year, 0, from, from.AddDate(1, 0, 0))
+}
+
+// archiveMonthView lists the posts of a month. Months are written with
+// two digits, e.g. /archive/2024/03.
+func (app *application) archiveMonthView(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	year, err := strconv.Atoi(params.ByName("year"))
+	if err != nil || year < 1 || year > 9999 {
+		app.notFound(w, r)
+		return
+	}
+
+	month, err := strconv.Atoi(params.ByName("month"))
+	if err != nil || month < 1 || month > 12 || len(params.ByName("month")) != 2 {
+		app.notFound(w, r)
+		return
+	}
+
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+
+	app.renderArchivePeriod(w, r, year, time.Month(month), from, from.AddDate(0, 1, 0))
+}
+
+func (app *application) renderArchivePeriod(w http.ResponseWriter, r *http.Request, year int, month time.Month, from, to time.Time) {
+	posts, err := app.posts.GetBetween(from, to)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if len(posts) == 0 {
+		app.notFound(w, r)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Posts = posts
+	data.ArchiveYear = year
+	data.ArchiveMonth = month
+	app.renderTemplate(w, r, http.StatusOK, "archive_period.html", data)
+}
+
+// baseURL returns the URL the site is reached at, without a trailing
+// slash, for building absolute links.
+func (app *application) baseURL(r *http.Request) string {
+	if app.config.BaseURL != "" {
+		return app.config.BaseURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// sitemap lists the pages search engines should crawl: the home page, the
+// archive, every public post, the profiles of their authors and the pages
+// of the hashtags they use. A page's last modification is when a post on
+// it was last changed.
+func (app *application) sitemap(w http.ResponseWriter, r *http.Request) {
+	posts, err := app.posts.Latest(models.SortNewest)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	hashtags, err := app.posts.Hashtags()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	years, err := app.archive()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	base := app.baseURL(r)
+	lastMod := func(t time.Time) string { return t.UTC().Format(time.RFC3339) }
+
+	set := sitemapURLSet{}
+
+	home := sitemapURL{Loc: base + "/"}
+	if len(years) > 0 {
+		home.LastMod = lastMod(years[0].Months[0].Updated)
+	}
+	set.URLs = append(set.URLs, home, sitemapURL{Loc: base + "/archive", LastMod: home.LastMod})
+
+	// The newest month of a year holds its latest post.
+	for _, year := range years {
+		set.URLs = append(set.URLs, sitemapURL{Loc: fmt.Sprintf("%s/archive/%d", base, year.Year), LastMod: lastMod(year.Months[0].Updated)})
+
+		for _, month := range year.Months {
+			set.URLs = append(set.URLs, sitemapURL{Loc: fmt.Sprintf("%s/archive/%d/%02d", base, month.Year, month.Month), LastMod: lastMod(month.Updated)})
+		}
+	}
+
+	// Authors' profiles change with their latest post.
+	authors := []string{}
+	authorUpdated := map[string]time.Time{}
+
+	for _, post := range posts {
+		set.URLs = append(set.URLs, sitemapURL{Loc: fmt.Sprintf("%s/post/view/%d", base, post.Id), LastMod: lastMod(post.Updated)})
+
+		if post.Author == "" {
+			continue
+		}
+		if _, ok := authorUpdated[post.Author]; !ok {
+			authors = append(authors, post.Author)
+		}
+		if post.Updated.After(authorUpdated[post.Author]) {
+			authorUpdated[post.Author] = post.Updated
+		}
+	}
+
+	for _, author := range authors {
+		set.URLs = append(set.URLs, sitemapURL{Loc: fmt.Sprintf("%s/u/%s", base, author), LastMod: lastMod(authorUpdated[author])})
+	}
+
+	for _, hashtag := range hashtags {
+		set.URLs = append(set.URLs, sitemapURL{Loc: base + "/hashtag/" + url.PathEscape(hashtag.Tag), LastMod: lastMod(hashtag.Updated)})
+	}
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+}
+
+// robotsDisallow are the paths crawlers are asked to stay out of: pages
+// that need an account and forms.
+var robotsDisallow = []string{"/admin", "/review", "/trash", "/user/", "/following", "/post/add", "/post/edit/"}
+
+func (app *application) robots(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+
+	b.WriteString("User-agent: *\n")
+	for _, path := range robotsDisallow {
+		fmt.Fprintf(&b, "Disallow: %s\n", path)
+	}
+	fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", app.baseURL(r))
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
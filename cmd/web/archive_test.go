@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestArchive(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	posts := []*models.Post{
+		{Title: "Spring cleaning", Created: time.Date(2023, 3, 5, 9, 0, 0, 0, time.UTC)},
+		{Title: "March again", Created: time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC)},
+		{Title: "Leap day", Created: time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{Title: "Secret plans", Created: time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC), Visibility: models.PostVisibilityPrivate},
+	}
+
+	for _, post := range posts {
+		_, err := app.posts.Import(post)
+		assert.NilError(t, err)
+	}
+
+	code, _, body := ts.get(t, "/archive")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, `<a href="/archive/2024">2024</a>`)
+	assert.StringContains(t, body, `<a href="/archive/2024/03">March 2024</a>`)
+	assert.StringContains(t, body, "(2 posts)")
+	assert.Equal(t, strings.Index(body, "/archive/2024/03") < strings.Index(body, "/archive/2024/02"), true)
+	assert.Equal(t, strings.Index(body, "/archive/2024/02") < strings.Index(body, "/archive/2023/03"), true)
+
+	_, _, body = ts.get(t, "/archive/2024")
+	assert.StringContains(t, body, "March again")
+	assert.StringContains(t, body, "Leap day")
+	assert.Equal(t, strings.Contains(body, "Spring cleaning"), false)
+	assert.Equal(t, strings.Contains(body, "Secret plans"), false)
+
+	_, _, body = ts.get(t, "/archive/2024/02")
+	assert.StringContains(t, body, "<h1>February 2024</h1>")
+	assert.StringContains(t, body, "Leap day")
+	assert.Equal(t, strings.Contains(body, "March again"), false)
+
+	tests := []struct {
+		name    string
+		urlPath string
+	}{
+		{"Year without posts", "/archive/2022"},
+		{"Month without posts", "/archive/2024/04"},
+		{"Invalid month", "/archive/2024/13"},
+		{"Single-digit month", "/archive/2024/3"},
+		{"Invalid year", "/archive/abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.get(t, tt.urlPath)
+			assert.Equal(t, code, http.StatusNotFound)
+		})
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	app := newTestApplication(t)
+	app.config.BaseURL = "https://blog.example.com"
+	ts := newTestServer(t, app.routes())
+
+	_, err := app.posts.Import(&models.Post{Title: "Hello", Created: time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC),
+		Updated: time.Date(2024, 4, 2, 8, 0, 0, 0, time.UTC)})
+	assert.NilError(t, err)
+	assert.NilError(t, app.posts.SetReferences(1, nil, []string{"haiku"}))
+	_, err = app.posts.Import(&models.Post{Title: "Unlisted", Created: time.Now(), Visibility: models.PostVisibilityUnlisted})
+	assert.NilError(t, err)
+	assert.NilError(t, app.posts.SetReferences(2, nil, []string{"hidden"}))
+
+	code, header, body := ts.get(t, "/sitemap.xml")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, header.Get("Content-Type"), "application/xml; charset=utf-8")
+	assert.StringContains(t, body, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.StringContains(t, body, "<loc>https://blog.example.com/</loc>")
+	assert.StringContains(t, body, "<loc>https://blog.example.com/archive/2024/03</loc>\n    <lastmod>2024-04-02T08:00:00Z</lastmod>")
+	assert.StringContains(t, body, "<loc>https://blog.example.com/post/view/1</loc>\n    <lastmod>2024-04-02T08:00:00Z</lastmod>")
+	assert.StringContains(t, body, "<loc>https://blog.example.com/hashtag/haiku</loc>\n    <lastmod>2024-04-02T08:00:00Z</lastmod>")
+	assert.Equal(t, strings.Contains(body, "/post/view/2"), false)
+	assert.Equal(t, strings.Contains(body, "/hashtag/hidden"), false)
+	assert.Equal(t, strings.Contains(body, "/archive/2024/04"), false)
+
+	code, _, body = ts.get(t, "/robots.txt")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "Disallow: /admin\n")
+	assert.StringContains(t, body, "Sitemap: https://blog.example.com/sitemap.xml")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// assets serves the static files. When fingerprinting is enabled each file
+// is also served under a name containing a hash of its content, e.g.
+// css/main.1a2b3c4d.css, which can be cached by browsers indefinitely
+// because the URL changes whenever the file does.
+type assets struct {
+	fsys        fs.FS
+	fileServer  http.Handler
+	fingerprint map[string]string
+	original    map[string]string
+}
+
+func newAssets(fsys fs.FS, fingerprint bool) (*assets, error) {
+	a := &assets{
+		fsys:        fsys,
+		fileServer:  http.FileServer(http.FS(fsys)),
+		fingerprint: map[string]string{},
+		original:    map[string]string{},
+	}
+
+	if !fingerprint {
+		return a, nil
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(b)
+		ext := path.Ext(name)
+		hashed := strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:4]) + ext
+
+		a.fingerprint[name] = hashed
+		a.original[hashed] = name
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// url returns the URL of the static file name, which is relative to the
+// static directory.
+func (a *assets) url(name string) string {
+	if hashed, ok := a.fingerprint[name]; ok {
+		return "/static/" + hashed
+	}
+
+	return "/static/" + name
+}
+
+func (a *assets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if name, ok := a.original[strings.TrimPrefix(r.URL.Path, "/")]; ok {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+
+		r = r.Clone(r.Context())
+		r.URL.Path = "/" + name
+		r.URL.RawPath = ""
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	a.fileServer.ServeHTTP(w, r)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// maxUserAgent is the length of the audit_log.user_agent column.
+const maxUserAgent = 512
+
+// audit records event in the audit log, filling in the client's address
+// and user agent and, unless the caller already set one, the logged in user
+// as the actor. before and after are stored as JSON if not nil. Failing to
+// record an event is logged but doesn't fail the request.
+func (app *application) audit(r *http.Request, event *models.AuditEvent, before, after any) {
+	if event.ActorId == 0 {
+		if user := app.authenticatedUser(r); user != nil {
+			event.ActorId = user.Id
+			event.Actor = user.Username
+		}
+	}
+
+	event.IP = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		event.IP = host
+	}
+
+	event.UserAgent = r.UserAgent()
+	if len(event.UserAgent) > maxUserAgent {
+		event.UserAgent = event.UserAgent[:maxUserAgent]
+	}
+
+	app.recordAudit(event, before, after)
+}
+
+// recordAudit records event as is, for events that don't come from a
+// request.
+func (app *application) recordAudit(event *models.AuditEvent, before, after any) {
+	var err error
+
+	event.Before, err = auditJSON(before)
+	if err == nil {
+		event.After, err = auditJSON(after)
+	}
+	if err == nil {
+		err = app.auditLog.Insert(event)
+	}
+	if err != nil {
+		app.logger.Error("recording audit event", "action", event.Action, "error", err)
+	}
+}
+
+func (app *application) auditLoginFailed(r *http.Request, username, reason string) {
+	app.audit(r, &models.AuditEvent{Action: models.AuditLoginFailed}, nil, map[string]string{
+		"username": username,
+		"reason":   reason,
+	})
+}
+
+func auditJSON(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(v)
+}
+
+// postAudit is what the audit log keeps of a post before and after a
+// change. The content itself isn't kept, since the post may be private,
+// only its SHA-256 hash and its length in characters to show whether and
+// how much it changed.
+type postAudit struct {
+	Title         string `json:"title"`
+	ContentHash   string `json:"content_sha256"`
+	ContentLength int    `json:"content_length"`
+	Status        string `json:"status,omitempty"`
+	Visibility    string `json:"visibility,omitempty"`
+	SeriesId      int    `json:"series_id,omitempty"`
+	UserId        int    `json:"user_id,omitempty"`
+	// Spam lists why the spam filter flagged the post.
+	Spam []string `json:"spam,omitempty"`
+}
+
+func newPostAudit(post *models.Post) *postAudit {
+	hash := sha256.Sum256([]byte(post.Content))
+
+	return &postAudit{Title: post.Title, ContentHash: hex.EncodeToString(hash[:]),
+		ContentLength: utf8.RuneCountInString(post.Content), Status: post.Status, Visibility: post.Visibility,
+		SeriesId: post.SeriesId, UserId: post.UserId}
+}
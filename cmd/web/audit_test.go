@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestAuditLog(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	form := url.Values{}
+	form.Add("username", "carol")
+	form.Add("password", "wrong")
+	form.Add("csrf_token", ts.csrfToken(t, "/user/login"))
+	ts.postForm(t, "/user/login", form)
+
+	ts.loginAdmin(t, app, "carol")
+
+	post := url.Values{}
+	post.Add("title", "First post")
+	post.Add("content", "Content")
+	post.Add("csrf_token", ts.csrfToken(t, "/post/add"))
+	ts.postForm(t, "/post/add", post)
+
+	post.Set("title", "Edited post")
+	ts.postForm(t, "/post/edit/1", post)
+
+	events, err := app.auditLog.List(models.AuditFilter{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 4)
+	assert.Equal(t, events[3].Action, models.AuditLoginFailed)
+	assert.Equal(t, events[3].ActorId, 0)
+	assert.Equal(t, string(events[3].After), `{"reason":"invalid credentials","username":"carol"}`)
+	assert.Equal(t, events[2].Action, models.AuditLogin)
+	assert.Equal(t, events[2].Actor, "carol")
+	assert.Equal(t, events[2].IP, "127.0.0.1")
+	assert.Equal(t, events[0].Action, models.AuditPostUpdate)
+	assert.Equal(t, events[0].TargetId, 1)
+	assert.StringContains(t, string(events[0].Before), `"title":"First post"`)
+	assert.StringContains(t, string(events[0].After), `"title":"Edited post"`)
+	// SHA-256 of "Content"
+	assert.StringContains(t, string(events[0].After),
+		`"content_sha256":"47bd29075f8b8019f0beec6d86beda7c9bf67aaf05053dcbe0b3bcb63968517f","content_length":7`)
+	assert.Equal(t, strings.Contains(string(events[0].After), `"Content"`), false)
+
+	code, _, body := ts.get(t, "/admin/audit?action=post.update")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "Edited post")
+
+	code, _, body = ts.get(t, "/admin/audit?since=yesterday")
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "This field must be a date")
+
+	code, header, body := ts.get(t, "/admin/audit/export?action=login.failed")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, header.Get("Content-Type"), "application/json")
+
+	var exported []*models.AuditEvent
+	assert.NilError(t, json.Unmarshal([]byte(body), &exported))
+	assert.Equal(t, len(exported), 1)
+	assert.Equal(t, exported[0].Action, models.AuditLoginFailed)
+}
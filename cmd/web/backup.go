@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/backup"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// runBackup implements the "backup" subcommand, which writes an archive of
+// all users, posts with their review histories, reactions and mentions,
+// series, follows and notifications.
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("web backup", flag.ContinueOnError)
+	out := fs.String("out", "", "file to write the archive to (default microblog-<date>.zip)")
+	includeSecrets := fs.Bool("include-secrets", false, "include password hashes in the archive")
+
+	app, code := newCLIApplication(fs, args)
+	if app == nil {
+		return code
+	}
+	defer app.db.Close()
+
+	if *out == "" {
+		*out = fmt.Sprintf("microblog-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	}
+
+	f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	err = app.writeBackup(f, *includeSecrets)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(*out)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("wrote %s\n", *out)
+
+	return 0
+}
+
+// runRestore implements the "restore" subcommand, which loads an archive
+// written by "backup". Users, posts, reviews, series and notifications keep
+// their IDs and existing records with the same IDs are overwritten, so
+// restoring the same archive twice has the same effect as restoring it
+// once. Posts' mentions and hashtags are found in their content again.
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("web restore", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "show what the archive contains without changing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: web restore [flags] <archive>")
+		fs.PrintDefaults()
+	}
+
+	app, code := newCLIApplication(fs, args)
+	if app == nil {
+		return code
+	}
+	defer app.db.Close()
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	b, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	a, err := backup.Read(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	m := a.Manifest
+	fmt.Printf("archive version %d created %s: %d users, %d posts, %d tags, %d reviews, %d series, %d reactions, "+
+		"%d follows, %d notifications, %d mentions (password hashes included: %t)\n", m.Version,
+		m.Created.Format(time.RFC3339), m.Users, m.Posts, m.Tags, m.Reviews, m.Series, m.Reactions, m.Follows,
+		m.Notifications, m.Mentions, m.IncludesSecrets)
+
+	if *dryRun {
+		return 0
+	}
+
+	err = app.restoreBackup(a)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Println("restore complete")
+
+	return 0
+}
+
+// newCLIApplication loads the configuration and connects to the database
+// for subcommands that only need the models. It returns a nil application
+// and the exit code if that fails.
+func newCLIApplication(fs *flag.FlagSet, args []string) (*application, int) {
+	cfg, _, err := loadConfig(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return nil, 2
+	}
+
+	db, err := openDB(cfg.DSN)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, 1
+	}
+
+	app := &application{
+		auditLog:      &models.AuditModel{DB: db},
+		config:        cfg,
+		db:            db,
+		notifications: &models.NotificationModel{DB: db},
+		posts:         &models.PostModel{DB: db, BcryptCost: cfg.BcryptCost},
+		series:        &models.SeriesModel{DB: db},
+		users:         &models.UserModel{DB: db, BcryptCost: cfg.BcryptCost},
+	}
+
+	return app, 0
+}
+
+func (app *application) writeBackup(w io.Writer, includeSecrets bool) error {
+	users, err := app.users.GetAll()
+	if err != nil {
+		return err
+	}
+
+	posts, err := app.posts.GetEverything()
+	if err != nil {
+		return err
+	}
+
+	reviews, err := app.posts.Reviews(0)
+	if err != nil {
+		return err
+	}
+
+	series, err := app.series.GetAll()
+	if err != nil {
+		return err
+	}
+
+	reactions, err := app.posts.AllReactions()
+	if err != nil {
+		return err
+	}
+
+	follows, err := app.users.AllFollows()
+	if err != nil {
+		return err
+	}
+
+	notifications, err := app.notifications.GetAll()
+	if err != nil {
+		return err
+	}
+
+	muted, err := app.notifications.AllMuted()
+	if err != nil {
+		return err
+	}
+
+	mentions, err := app.posts.AllMentions()
+	if err != nil {
+		return err
+	}
+
+	a := backup.New(users, posts, includeSecrets)
+	a.AddReviews(reviews)
+	a.AddSeries(series)
+	a.AddReactions(reactions)
+	a.AddFollows(follows)
+	a.AddNotifications(notifications, muted)
+	a.AddMentions(mentions)
+
+	return a.Write(w)
+}
+
+func (app *application) restoreBackup(a *backup.Archive) error {
+	for _, user := range a.Users {
+		err := app.users.Upsert(user.Model())
+		if err != nil {
+			return fmt.Errorf("restore: user %q: %w", user.Username, err)
+		}
+	}
+
+	series := map[int]bool{}
+
+	for _, s := range a.Series {
+		err := app.series.Upsert(s.Model())
+		if err != nil {
+			return fmt.Errorf("restore: series %q: %w", s.Slug, err)
+		}
+		series[s.Id] = true
+	}
+
+	for _, post := range a.Posts {
+		p := post.Model()
+		// Archives from before series were backed up have no series to
+		// point to.
+		if !series[p.SeriesId] {
+			p.SeriesId, p.SeriesPart = 0, 0
+		}
+
+		err := app.posts.Upsert(p)
+		if err != nil {
+			return fmt.Errorf("restore: post %d: %w", post.Id, err)
+		}
+
+		mentions, hashtags := models.ParseReferences(p.Content)
+
+		err = app.posts.SetReferences(p.Id, mentions, hashtags)
+		if err != nil {
+			return fmt.Errorf("restore: post %d: %w", post.Id, err)
+		}
+	}
+
+	for _, mention := range a.Mentions {
+		err := app.posts.UpsertMention(mention.Model())
+		if err != nil {
+			return fmt.Errorf("restore: mention of user %d in post %d: %w", mention.UserId, mention.PostId, err)
+		}
+	}
+
+	for _, review := range a.Reviews {
+		err := app.posts.UpsertReview(review.Model())
+		if err != nil {
+			return fmt.Errorf("restore: review %d: %w", review.Id, err)
+		}
+	}
+
+	for _, reaction := range a.Reactions {
+		err := app.posts.UpsertReaction(reaction.Model())
+		if err != nil {
+			return fmt.Errorf("restore: reaction to post %d: %w", reaction.PostId, err)
+		}
+	}
+
+	for _, follow := range a.Follows {
+		err := app.users.UpsertFollow(follow.Model())
+		if err != nil {
+			return fmt.Errorf("restore: follow of user %d by %d: %w", follow.FollowedId, follow.FollowerId, err)
+		}
+	}
+
+	for _, n := range a.Notifications {
+		err := app.notifications.Upsert(n.Model())
+		if err != nil {
+			return fmt.Errorf("restore: notification %d: %w", n.Id, err)
+		}
+	}
+
+	for _, mute := range a.Mutes {
+		err := app.notifications.SetMuted(mute.UserId, mute.Kinds)
+		if err != nil {
+			return fmt.Errorf("restore: muted notifications of user %d: %w", mute.UserId, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/backup"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestBackupRestore(t *testing.T) {
+	src := newTestApplication(t)
+
+	assert.NilError(t, src.users.Insert("alice", "alice@example.com", "pa$$word"))
+	draft := &models.Post{UserId: 1, Title: "Kept", Content: "Content", Status: models.PostStatusDraft,
+		Visibility: models.PostVisibilityPublic}
+	learning := &models.Series{Slug: "learning-go", Title: "Learning Go"}
+	_, err := src.posts.Save(draft, learning, 1, "", models.ReviewSubmit)
+	assert.NilError(t, err)
+	kept, seriesId := draft.Id, learning.Id
+	_, err = src.posts.React(kept, 1, models.ReactionLike)
+	assert.NilError(t, err)
+	assert.NilError(t, src.users.Insert("bobby", "bob@example.com", "pa$$word"))
+	assert.NilError(t, src.users.Follow(2, 1))
+	assert.NilError(t, src.notifications.Insert(&models.Notification{UserId: 1, ActorId: 2, Kind: models.NotificationFollow}))
+	assert.NilError(t, src.notifications.SetMuted(2, []string{models.NotificationReaction}))
+	mentioning := newPost(t, src, 1, "Hello", "Hi @bobby, welcome to #golang", models.PostStatusPublished,
+		models.PostVisibilityPublic)
+	assert.NilError(t, src.posts.SetReferences(mentioning, []string{"bobby"}, []string{"golang"}))
+	_, err = src.posts.ClaimMentions(mentioning)
+	assert.NilError(t, err)
+	trashed := newPost(t, src, 1, "Trashed", "Content", models.PostStatusPublished, models.PostVisibilityPublic)
+	assert.NilError(t, src.posts.Delete(trashed))
+
+	var buf bytes.Buffer
+	assert.NilError(t, src.writeBackup(&buf, false))
+
+	a, err := backup.Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NilError(t, err)
+	assert.Equal(t, a.Manifest.Users, 2)
+	assert.Equal(t, a.Manifest.Posts, 3)
+
+	dst := newTestApplication(t)
+
+	for range 2 {
+		assert.NilError(t, dst.restoreBackup(a))
+
+		users, err := dst.users.GetAll()
+		assert.NilError(t, err)
+		assert.Equal(t, len(users), 2)
+
+		posts, err := dst.posts.GetEverything()
+		assert.NilError(t, err)
+		assert.Equal(t, len(posts), 3)
+
+		trash, err := dst.posts.GetDeleted(0)
+		assert.NilError(t, err)
+		assert.Equal(t, len(trash), 1)
+		assert.Equal(t, trash[0].Id, trashed)
+
+		reviews, err := dst.posts.Reviews(kept)
+		assert.NilError(t, err)
+		assert.Equal(t, len(reviews), 1)
+		assert.Equal(t, reviews[0].Action, models.ReviewSubmit)
+
+		series, err := dst.series.GetBySlug("learning-go")
+		assert.NilError(t, err)
+		assert.Equal(t, series.Id, seriesId)
+
+		parts, err := dst.posts.GetBySeries(seriesId)
+		assert.NilError(t, err)
+		assert.Equal(t, len(parts), 1)
+		assert.Equal(t, parts[0].Id, kept)
+
+		reactions, err := dst.posts.Reactions(kept, 1)
+		assert.NilError(t, err)
+		assert.Equal(t, *reactions[0], models.ReactionCount{Kind: models.ReactionLike, Count: 1, Reacted: true})
+
+		post, err := dst.posts.Get(kept)
+		assert.NilError(t, err)
+		assert.Equal(t, post.Reactions, 1)
+
+		following, err := dst.users.IsFollowing(2, 1)
+		assert.NilError(t, err)
+		assert.Equal(t, following, true)
+
+		notifications, err := dst.notifications.List(1, 10)
+		assert.NilError(t, err)
+		assert.Equal(t, len(notifications), 1)
+		assert.Equal(t, notifications[0].Actor, "bobby")
+
+		muted, err := dst.notifications.Muted(2)
+		assert.NilError(t, err)
+		assert.Equal(t, strings.Join(muted, ","), models.NotificationReaction)
+
+		tagged, err := dst.posts.LatestByHashtag("golang")
+		assert.NilError(t, err)
+		assert.Equal(t, len(tagged), 1)
+		assert.Equal(t, tagged[0].Id, mentioning)
+
+		pending, err := dst.posts.ClaimMentions(mentioning)
+		assert.NilError(t, err)
+		assert.Equal(t, len(pending), 0)
+	}
+}
+
+func TestRestoreDropsMissingSeries(t *testing.T) {
+	app := newTestApplication(t)
+
+	a := backup.New(nil, []*models.Post{{Id: 1, Title: "Part", Content: "Content", SeriesId: 7, SeriesPart: 1}}, false)
+	assert.NilError(t, app.restoreBackup(a))
+
+	post, err := app.posts.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, post.SeriesId, 0)
+}
+
+func TestBackupRestoreKeepsPassphrases(t *testing.T) {
+	app := newTestApplication(t)
+
+	assert.NilError(t, app.users.Insert("alice", "alice@example.com", "pa$$word"))
+	protected := &models.Post{UserId: 1, Title: "Protected", Content: "Content", Status: models.PostStatusPublished,
+		Visibility: models.PostVisibilityProtected}
+	_, err := app.posts.Save(protected, nil, 1, "open sesame", "")
+	assert.NilError(t, err)
+	id := protected.Id
+
+	var buf bytes.Buffer
+	assert.NilError(t, app.writeBackup(&buf, false))
+
+	a, err := backup.Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NilError(t, err)
+	assert.NilError(t, app.restoreBackup(a))
+
+	post, err := app.posts.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, post.CheckPassword("open sesame"), true)
+}
+
+func TestUserExportPosts(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	ts.login(t, app, "alice", "pa$$word")
+	assert.NilError(t, app.users.Insert("bobby", "bob@example.com", "pa$$word"))
+
+	newPost(t, app, 1, "Mine", "Content", models.PostStatusPublished, models.PostVisibilityPublic)
+	newPost(t, app, 2, "Not mine", "Content", models.PostStatusPublished, models.PostVisibilityPublic)
+
+	code, header, body := ts.get(t, "/user/export")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, header.Get("Content-Type"), "application/zip")
+
+	zr, err := zip.NewReader(strings.NewReader(body), int64(len(body)))
+	assert.NilError(t, err)
+	assert.Equal(t, len(zr.File), 1)
+	assert.Equal(t, zr.File[0].Name, "mine.md")
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is prepended to the upper-cased flag name to form the name of
+// the environment variable for a setting, e.g. -session-lifetime is read
+// from MICROBLOG_SESSION_LIFETIME.
+const envPrefix = "MICROBLOG_"
+
+type config struct {
+	Addr            string        `yaml:"addr"`
+	BaseURL         string        `yaml:"base-url"`
+	DSN             string        `yaml:"dsn"`
+	SessionLifetime time.Duration `yaml:"session-lifetime"`
+	Dev             bool          `yaml:"dev"`
+	UIDir           string        `yaml:"ui-dir"`
+	BcryptCost      int           `yaml:"bcrypt-cost"`
+	CSP             string        `yaml:"csp"`
+	TrashRetention  time.Duration `yaml:"trash-retention"`
+	RequireReview   bool          `yaml:"require-review"`
+	LogFormat       string        `yaml:"log-format"`
+	LogLevel        string        `yaml:"log-level"`
+
+	MinFormTime    time.Duration `yaml:"min-form-time"`
+	PostRateLimit  int           `yaml:"post-rate-limit"`
+	PostRateWindow time.Duration `yaml:"post-rate-window"`
+
+	ReadTimeout     time.Duration `yaml:"read-timeout"`
+	WriteTimeout    time.Duration `yaml:"write-timeout"`
+	IdleTimeout     time.Duration `yaml:"idle-timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown-timeout"`
+
+	TLSCert           string        `yaml:"tls-cert"`
+	TLSKey            string        `yaml:"tls-key"`
+	TLSReloadInterval time.Duration `yaml:"tls-reload-interval"`
+	RedirectAddr      string        `yaml:"redirect-addr"`
+
+	MetricsAddr string `yaml:"metrics-addr"`
+}
+
+// loadConfig builds the configuration from, in increasing order of
+// precedence, the built-in defaults, the YAML file named by -config, the
+// MICROBLOG_* environment variables and the command-line flags. It returns
+// whether -print-config was given. Callers may define additional flags of
+// their own on fs before calling loadConfig.
+func loadConfig(fs *flag.FlagSet, args []string) (*config, bool, error) {
+	cfg := &config{}
+
+	configFile := fs.String("config", os.Getenv(envPrefix+"CONFIG"), "path to a YAML configuration file")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration and exit")
+
+	fs.StringVar(&cfg.Addr, "addr", ":4000", "http network address")
+	fs.StringVar(&cfg.BaseURL, "base-url", "", "public URL of the site, e.g. https://blog.example.com, for absolute links such as those in the sitemap; defaults to the scheme and host of each request")
+	fs.StringVar(&cfg.DSN, "dsn", "web:pass@/microblog?parseTime=true", "mysql data source name")
+	fs.DurationVar(&cfg.SessionLifetime, "session-lifetime", 12*time.Hour, "how long a session lasts")
+	fs.BoolVar(&cfg.Dev, "dev", false, "serve templates and static files from -ui-dir instead of the embedded copies and reload templates on change")
+	fs.StringVar(&cfg.UIDir, "ui-dir", "./ui", "directory containing the html and static directories; only used with -dev")
+	fs.IntVar(&cfg.BcryptCost, "bcrypt-cost", 12, "bcrypt cost used to hash passwords")
+	fs.StringVar(&cfg.CSP, "csp", "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' https:", "value of the Content-Security-Policy header")
+	fs.DurationVar(&cfg.TrashRetention, "trash-retention", 30*24*time.Hour, "how long deleted posts are kept in the trash")
+	fs.BoolVar(&cfg.RequireReview, "require-review", true, "submit new posts for review instead of publishing them, unless the author is a reviewer")
+	fs.StringVar(&cfg.LogFormat, "log-format", "text", "log output format (text|json)")
+	fs.StringVar(&cfg.LogLevel, "log-level", "info", "minimum log level (debug|info|warn|error)")
+
+	fs.DurationVar(&cfg.MinFormTime, "min-form-time", 3*time.Second, "submissions of the register and post forms faster than this are rejected as automated; 0 disables the check")
+	fs.IntVar(&cfg.PostRateLimit, "post-rate-limit", 10, "how many posts a user may write per -post-rate-window; 0 disables the limit")
+	fs.DurationVar(&cfg.PostRateWindow, "post-rate-window", time.Hour, "the period -post-rate-limit applies to")
+
+	fs.DurationVar(&cfg.ReadTimeout, "read-timeout", 5*time.Second, "maximum duration for reading a request")
+	fs.DurationVar(&cfg.WriteTimeout, "write-timeout", 10*time.Second, "maximum duration for writing a response")
+	fs.DurationVar(&cfg.IdleTimeout, "idle-timeout", time.Minute, "how long idle keep-alive connections are kept open")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", 30*time.Second, "how long to wait for in-flight requests on shutdown")
+
+	fs.StringVar(&cfg.TLSCert, "tls-cert", "", "path to the tls certificate; enables https when set with -tls-key")
+	fs.StringVar(&cfg.TLSKey, "tls-key", "", "path to the tls private key")
+	fs.DurationVar(&cfg.TLSReloadInterval, "tls-reload-interval", 0, "how often to check the tls files for changes; 0 disables reloading")
+	fs.StringVar(&cfg.RedirectAddr, "redirect-addr", "", "http network address that redirects to https; empty disables it")
+
+	fs.StringVar(&cfg.MetricsAddr, "metrics-addr", "localhost:4001", "http network address serving /metrics, kept apart from the public site; empty disables it")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, false, err
+	}
+
+	explicit := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+
+	if *configFile != "" {
+		err = cfg.readFile(*configFile)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	var errs []error
+
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" || f.Name == "print-config" {
+			return
+		}
+
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		if value, ok := os.LookupEnv(name); ok {
+			if err := fs.Set(f.Name, value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value %q for %s: %w", value, name, err))
+			}
+		}
+	})
+
+	for name, value := range explicit {
+		if err := fs.Set(name, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, false, errors.Join(errs...)
+	}
+
+	return cfg, *printConfig, cfg.validate()
+}
+
+func (cfg *config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+
+	err = dec.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (cfg *config) validate() error {
+	var errs []error
+
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("config: "+format, args...))
+		}
+	}
+
+	check(cfg.Addr != "", "addr must not be empty")
+	check(cfg.BaseURL == "" || validBaseURL(cfg.BaseURL), "base-url must be an absolute http or https URL without a trailing slash")
+	check(cfg.DSN != "", "dsn must not be empty")
+	check(cfg.SessionLifetime > 0, "session-lifetime must be positive")
+	check(!cfg.Dev || isDir(cfg.UIDir), "ui-dir %q is not a directory", cfg.UIDir)
+	check(cfg.BcryptCost >= bcrypt.MinCost && cfg.BcryptCost <= bcrypt.MaxCost,
+		"bcrypt-cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	check(cfg.CSP != "", "csp must not be empty")
+	check(cfg.TrashRetention > 0, "trash-retention must be positive")
+	check(cfg.LogFormat == "text" || cfg.LogFormat == "json", "log-format must be text or json")
+	check(new(slog.Level).UnmarshalText([]byte(cfg.LogLevel)) == nil, "log-level %q is invalid", cfg.LogLevel)
+	check(cfg.MinFormTime >= 0, "min-form-time must not be negative")
+	check(cfg.PostRateLimit >= 0, "post-rate-limit must not be negative")
+	check(cfg.PostRateWindow > 0, "post-rate-window must be positive")
+	check(cfg.ReadTimeout > 0, "read-timeout must be positive")
+	check(cfg.WriteTimeout > 0, "write-timeout must be positive")
+	check(cfg.IdleTimeout > 0, "idle-timeout must be positive")
+	check(cfg.ShutdownTimeout > 0, "shutdown-timeout must be positive")
+	check((cfg.TLSCert == "") == (cfg.TLSKey == ""), "tls-cert and tls-key must be set together")
+	check(cfg.TLSReloadInterval >= 0, "tls-reload-interval must not be negative")
+	check(cfg.TLSReloadInterval == 0 || cfg.tlsEnabled(), "tls-reload-interval requires tls-cert and tls-key")
+	check(cfg.RedirectAddr == "" || cfg.tlsEnabled(), "redirect-addr requires tls-cert and tls-key")
+	check(cfg.MetricsAddr == "" || cfg.MetricsAddr != cfg.Addr && cfg.MetricsAddr != cfg.RedirectAddr,
+		"metrics-addr must differ from addr and redirect-addr")
+
+	if cfg.DSN != "" {
+		_, err := mysql.ParseDSN(cfg.DSN)
+		check(err == nil, "dsn is invalid: %v", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// write prints the configuration as YAML with the database password
+// redacted.
+func (cfg *config) write(w io.Writer) error {
+	out := *cfg
+
+	if dsn, err := mysql.ParseDSN(out.DSN); err == nil && dsn.Passwd != "" {
+		dsn.Passwd = "REDACTED"
+		out.DSN = dsn.FormatDSN()
+	}
+
+	enc := yaml.NewEncoder(w)
+	defer enc.Close()
+
+	return enc.Encode(out)
+}
+
+func (cfg *config) tlsEnabled() bool {
+	return cfg.TLSCert != "" && cfg.TLSKey != ""
+}
+
+func validBaseURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		!strings.HasSuffix(s, "/") && u.RawQuery == "" && u.Fragment == ""
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -2,4 +2,9 @@ package main
 
 type contextKey string
 
-const isAuthenticatedContextKey = contextKey("isAuthenticated")
+const (
+	isAuthenticatedContextKey   = contextKey("isAuthenticated")
+	authenticatedUserContextKey = contextKey("authenticatedUser")
+	requestInfoContextKey       = contextKey("requestInfo")
+	localizerContextKey         = contextKey("localizer")
+)
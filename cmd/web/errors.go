@@ -1,22 +1,144 @@
 package main
 
 import (
-	"fmt"
+	"encoding/json"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/anxxuj/microblog/internal/i18n"
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/justinas/nosurf"
 )
 
-func (app *application) serverError(w http.ResponseWriter, err error) {
-	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
-	app.errorLog.Output(2, trace)
+// errorMessages explains the error statuses the application responds with
+// to readers. Other statuses only show their status text.
+var errorMessages = map[int]string{
+	http.StatusBadRequest:          "The request could not be understood. Please go back and try again.",
+	http.StatusForbidden:           "You don't have permission to view this page.",
+	http.StatusNotFound:            "The page you were looking for doesn't exist or has been removed.",
+	http.StatusMethodNotAllowed:    "This page doesn't support that kind of request.",
+	http.StatusConflict:            "This isn't possible in the current state of the page. Please reload it and try again.",
+	http.StatusUnprocessableEntity: "The submitted data could not be processed.",
+	http.StatusInternalServerError: "Something went wrong on our side. Please try again later.",
+}
+
+// errorPage is the error shown by error.html. ID is the request ID, which
+// readers can quote so the error can be found in the logs.
+type errorPage struct {
+	Status  int
+	Title   string
+	Message string
+	ID      string
+}
+
+func (app *application) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Error(err.Error(),
+		"request_id", requestInfoFromRequest(r).id,
+		"method", r.Method,
+		"uri", r.URL.RequestURI(),
+		"stack", string(debug.Stack()),
+	)
+
+	app.renderError(w, r, http.StatusInternalServerError)
+}
+
+func (app *application) clientError(w http.ResponseWriter, r *http.Request, status int) {
+	app.renderError(w, r, status)
+}
 
-	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+func (app *application) notFound(w http.ResponseWriter, r *http.Request) {
+	app.clientError(w, r, http.StatusNotFound)
 }
 
-func (app *application) clientError(w http.ResponseWriter, status int) {
-	http.Error(w, http.StatusText(status), status)
+// methodNotAllowed is the router's MethodNotAllowed handler. The router has
+// already set the Allow header.
+func (app *application) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	app.clientError(w, r, http.StatusMethodNotAllowed)
 }
 
-func (app *application) notFound(w http.ResponseWriter) {
-	app.clientError(w, http.StatusNotFound)
+// renderError responds with an error page for status, or with a JSON
+// object if the client prefers JSON over HTML. If the error page itself
+// can't be rendered it falls back to plain text.
+func (app *application) renderError(w http.ResponseWriter, r *http.Request, status int) {
+	page := &errorPage{
+		Status:  status,
+		Title:   http.StatusText(status),
+		Message: errorMessages[status],
+		ID:      requestInfoFromRequest(r).id,
+	}
+
+	if prefersJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{
+			"status":   page.Status,
+			"error":    page.Title,
+			"message":  page.Message,
+			"error_id": page.ID,
+		})
+		return
+	}
+
+	data := app.errorTemplateData(r)
+	data.Error = page
+
+	buf, err := app.executeTemplate("error.html", data)
+	if err != nil {
+		app.logger.Error("rendering error page", "request_id", page.ID, "error", err)
+		http.Error(w, page.Title, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	buf.WriteTo(w)
+}
+
+// errorTemplateData is like newTemplateData but also works for requests
+// that failed before or outside of the session middleware, such as ones
+// the router didn't match. The flash message is left for the next page.
+func (app *application) errorTemplateData(r *http.Request) *tempateData {
+	localizer := app.localizer(r)
+	if localizer == nil {
+		localizer = i18n.New(i18n.Negotiate(r.Header.Get("Accept-Language")), nil)
+	}
+
+	return &tempateData{
+		CSRFToken:       nosurf.Token(r),
+		IsAdmin:         app.authenticatedUser(r).Can(models.PermissionAdmin),
+		IsAuthenticated: app.isAuthenticated(r),
+		IsReviewer:      app.authenticatedUser(r).Can(models.PermissionReview),
+		Localizer:       localizer,
+	}
+}
+
+// prefersJSON reports whether the Accept header of r ranks application/json
+// above text/html. Browsers always ask for HTML, so errors default to HTML.
+func prefersJSON(r *http.Request) bool {
+	jsonQ, htmlQ := -1.0, -1.0
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		case "text/html":
+			htmlQ = max(htmlQ, q)
+		}
+	}
+
+	return jsonQ > 0 && jsonQ > htmlQ
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestErrorPages(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	tests := []struct {
+		name      string
+		method    string
+		urlPath   string
+		accept    string
+		language  string
+		wantCode  int
+		wantAllow string
+		wantBody  string
+	}{
+		{"Not found", http.MethodGet, "/missing", "text/html", "", http.StatusNotFound, "", "The page you were looking for doesn&#39;t exist"},
+		{"Not found in German", http.MethodGet, "/missing", "text/html", "de", http.StatusNotFound, "", "Nicht gefunden"},
+		{"Method not allowed", http.MethodDelete, "/", "", "", http.StatusMethodNotAllowed, "GET, OPTIONS", "405 Method Not Allowed"},
+		{"Missing post", http.MethodGet, "/post/view/99", "", "", http.StatusNotFound, "", `<a href="/user/login">Login</a>`},
+		{"CSRF failure", http.MethodPost, "/user/login", "", "", http.StatusBadRequest, "", "400 Bad Request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.urlPath, nil)
+			assert.NilError(t, err)
+			req.Header.Set("Accept", tt.accept)
+			req.Header.Set("Accept-Language", tt.language)
+
+			rs, err := ts.Client().Do(req)
+			assert.NilError(t, err)
+			code, header, body := readResponse(t, rs)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Allow"), tt.wantAllow)
+			assert.Equal(t, header.Get("Content-Type"), "text/html; charset=utf-8")
+			assert.StringContains(t, body, tt.wantBody)
+			assert.StringContains(t, body, header.Get("X-Request-ID"))
+		})
+	}
+}
+
+func TestErrorJSON(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/post/view/99", nil)
+	assert.NilError(t, err)
+	req.Header.Set("Accept", "application/json")
+
+	rs, err := ts.Client().Do(req)
+	assert.NilError(t, err)
+	code, header, body := readResponse(t, rs)
+
+	assert.Equal(t, code, http.StatusNotFound)
+	assert.Equal(t, header.Get("Content-Type"), "application/json")
+
+	var got struct {
+		Status  int    `json:"status"`
+		Error   string `json:"error"`
+		ErrorID string `json:"error_id"`
+	}
+	err = json.Unmarshal([]byte(body), &got)
+	assert.NilError(t, err)
+	assert.Equal(t, got.Status, http.StatusNotFound)
+	assert.Equal(t, got.Error, "Not Found")
+	assert.Equal(t, got.ErrorID, header.Get("X-Request-ID"))
+}
+
+func TestServerErrorPage(t *testing.T) {
+	app := newTestApplication(t)
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	app.requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.serverError(w, r, errors.New("boom"))
+	})).ServeHTTP(rr, r)
+
+	rs := rr.Result()
+	id := rs.Header.Get("X-Request-ID")
+
+	assert.Equal(t, rs.StatusCode, http.StatusInternalServerError)
+	assert.StringContains(t, rr.Body.String(), "Something went wrong on our side")
+	assert.StringContains(t, rr.Body.String(), "<code>"+id+"</code>")
+}
+
+func TestPrefersJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", true},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false},
+		{"text/html;q=0.5, application/json", true},
+		{"application/json;q=0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", tt.accept)
+			assert.Equal(t, prefersJSON(r), tt.want)
+		})
+	}
+}
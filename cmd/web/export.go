@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// runExport implements the "export" subcommand, which renders the blog to
+// a directory of static HTML files that can be served by any web server.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("web export", flag.ContinueOnError)
+	out := fs.String("out", "./public", "directory to write the static site to")
+	basePath := fs.String("base-path", "", "path prefix the site will be served under, e.g. /blog")
+
+	cfg, printConfig, err := loadConfig(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if printConfig {
+		err = cfg.write(os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	if *basePath != "" && !strings.HasPrefix(*basePath, "/") {
+		fmt.Fprintln(os.Stderr, "export: base-path must start with /")
+		return 2
+	}
+
+	logger, err := newLogger(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	db, err := openDB(cfg.DSN)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	defer db.Close()
+
+	uiFS, assets, templateCache, err := loadUI(cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	app := &application{
+		assets:        assets,
+		config:        cfg,
+		logger:        logger,
+		metrics:       newMetrics(nil),
+		posts:         &models.PostModel{DB: db},
+		templateCache: templateCache,
+		ui:            uiFS,
+	}
+
+	n, err := app.exportSite(*out, strings.TrimSuffix(*basePath, "/"))
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	logger.Info("exported site", "dir", *out, "files", n)
+
+	return 0
+}
+
+// exportSite writes the index, the archive, every post, the hashtag pages
+// and the static files to dir and returns the number of files written. Each page is written as index.html
+// in a directory named after its URL, so existing links keep working on
+// static hosts; root-relative links are prefixed with basePath.
+func (app *application) exportSite(dir, basePath string) (int, error) {
+	posts, err := app.posts.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+
+	render := func(urlPath, page string, data *tempateData) error {
+		data.StaticExport = true
+
+		buf, err := app.executeTemplate(page, data)
+		if err != nil {
+			return err
+		}
+
+		n++
+		return writeExportFile(dir, path.Join(urlPath, "index.html"), rewriteLinks(buf.Bytes(), basePath))
+	}
+
+	err = render("/", "index.html", &tempateData{Posts: posts})
+	if err != nil {
+		return n, err
+	}
+
+	for _, post := range posts {
+		err = render(fmt.Sprintf("/post/view/%d", post.Id), "post.html", &tempateData{Post: post})
+		if err != nil {
+			return n, err
+		}
+	}
+
+	err = app.exportArchive(render)
+	if err != nil {
+		return n, err
+	}
+
+	err = app.exportHashtags(render)
+	if err != nil {
+		return n, err
+	}
+
+	err = fs.WalkDir(app.assets.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		b, err := fs.ReadFile(app.assets.fsys, name)
+		if err != nil {
+			return err
+		}
+
+		names := []string{name}
+		if hashed, ok := app.assets.fingerprint[name]; ok {
+			names = append(names, hashed)
+		}
+
+		for _, name := range names {
+			err = writeExportFile(dir, path.Join("static", name), b)
+			if err != nil {
+				return err
+			}
+			n++
+		}
+
+		return nil
+	})
+
+	return n, err
+}
+
+// exportArchive renders the archive and the page of every year and month
+// with posts.
+func (app *application) exportArchive(render func(urlPath, page string, data *tempateData) error) error {
+	years, err := app.archive()
+	if err != nil {
+		return err
+	}
+
+	err = render("/archive", "archive.html", &tempateData{Archive: years})
+	if err != nil {
+		return err
+	}
+
+	for _, year := range years {
+		from := time.Date(year.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		posts, err := app.posts.GetBetween(from, from.AddDate(1, 0, 0))
+		if err != nil {
+			return err
+		}
+
+		err = render(fmt.Sprintf("/archive/%d", year.Year), "archive_period.html",
+			&tempateData{Posts: posts, ArchiveYear: year.Year})
+		if err != nil {
+			return err
+		}
+
+		for _, month := range year.Months {
+			from := time.Date(month.Year, month.Month, 1, 0, 0, 0, 0, time.UTC)
+
+			posts, err := app.posts.GetBetween(from, from.AddDate(0, 1, 0))
+			if err != nil {
+				return err
+			}
+
+			err = render(fmt.Sprintf("/archive/%d/%02d", month.Year, month.Month), "archive_period.html",
+				&tempateData{Posts: posts, ArchiveYear: month.Year, ArchiveMonth: month.Month})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// exportHashtags renders the page of every hashtag used by a published,
+// public post.
+func (app *application) exportHashtags(render func(urlPath, page string, data *tempateData) error) error {
+	hashtags, err := app.posts.Hashtags()
+	if err != nil {
+		return err
+	}
+
+	for _, hashtag := range hashtags {
+		posts, err := app.posts.LatestByHashtag(hashtag.Tag)
+		if err != nil {
+			return err
+		}
+
+		err = render("/hashtag/"+hashtag.Tag, "hashtag.html", &tempateData{Hashtag: hashtag.Tag, Posts: posts})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeExportFile(dir, name string, b []byte) error {
+	dst := filepath.Join(dir, filepath.FromSlash(name))
+
+	err := os.MkdirAll(filepath.Dir(dst), 0o755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(dst, b, 0o644)
+}
+
+var rootRelativeLinkRX = regexp.MustCompile(`\b(href|src|action)="/`)
+
+// rewriteLinks prefixes root-relative URLs in the href, src and action
+// attributes of page with basePath.
+func rewriteLinks(page []byte, basePath string) []byte {
+	if basePath == "" {
+		return page
+	}
+
+	return rootRelativeLinkRX.ReplaceAll(page, []byte(`$1="`+basePath+`/`))
+}
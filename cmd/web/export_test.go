@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestExportSite(t *testing.T) {
+	app := newTestApplication(t)
+
+	newPost(t, app, 1, "First post", "Hello #golang", models.PostStatusPublished, models.PostVisibilityPublic)
+	id := newPost(t, app, 1, "Deleted post", "Gone", models.PostStatusPublished, models.PostVisibilityPublic)
+	assert.NilError(t, app.posts.Delete(id))
+
+	dir := t.TempDir()
+
+	_, err := app.exportSite(dir, "/blog")
+	assert.NilError(t, err)
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	assert.NilError(t, err)
+	assert.StringContains(t, string(index), `<a href="/blog/post/view/1">First post</a>`)
+	assert.StringContains(t, string(index), `href="/blog`+app.assets.url("css/main.css"))
+
+	post, err := os.ReadFile(filepath.Join(dir, "post", "view", "1", "index.html"))
+	assert.NilError(t, err)
+	assert.StringContains(t, string(post), "Hello")
+
+	_, err = os.Stat(filepath.Join(dir, "post", "view", "2", "index.html"))
+	assert.Equal(t, os.IsNotExist(err), true)
+
+	now := time.Now().UTC()
+	month, err := os.ReadFile(filepath.Join(dir, "archive", now.Format("2006"), now.Format("01"), "index.html"))
+	assert.NilError(t, err)
+	assert.StringContains(t, string(month), `<a href="/blog/post/view/1">First post</a>`)
+
+	hashtag, err := os.ReadFile(filepath.Join(dir, "hashtag", "golang", "index.html"))
+	assert.NilError(t, err)
+	assert.StringContains(t, string(hashtag), `<a href="/blog/post/view/1">First post</a>`)
+
+	_, err = os.Stat(filepath.Join(dir, filepath.FromSlash(app.assets.url("css/main.css"))))
+	assert.NilError(t, err)
+}
+
+func TestRewriteLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		basePath string
+		want     string
+	}{
+		{"No base path", `<a href="/post/view/1">`, "", `<a href="/post/view/1">`},
+		{"Root-relative link", `<a href="/post/view/1">`, "/blog", `<a href="/blog/post/view/1">`},
+		{"Absolute link", `<a href="https://go.dev/">`, "/blog", `<a href="https://go.dev/">`},
+		{"Stylesheet", `<link rel="stylesheet" href="/static/css/main.css">`, "/blog", `<link rel="stylesheet" href="/blog/static/css/main.css">`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, string(rewriteLinks([]byte(tt.page), tt.basePath)), tt.want)
+		})
+	}
+}
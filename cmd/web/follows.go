@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+// timelinePageSize is how many posts a page of the following timeline
+// shows.
+const timelinePageSize = 20
+
+// profileUser returns the user named in the URL, or writes a not found or
+// server error response and returns nil.
+func (app *application) profileUser(w http.ResponseWriter, r *http.Request) *models.User {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	user, err := app.users.GetByUsername(params.ByName("username"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return nil
+	}
+
+	return user
+}
+
+// userProfile shows a user's public posts and who follows them.
+func (app *application) userProfile(w http.ResponseWriter, r *http.Request) {
+	user := app.profileUser(w, r)
+	if user == nil {
+		return
+	}
+
+	posts, err := app.posts.LatestByAuthor(user.Id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	counts, err := app.users.FollowCounts(user.Id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Profile = user
+	data.FollowCounts = counts
+	data.Posts = posts
+	data.IsAuthor = app.authenticatedUserID(r) == user.Id
+
+	if id := app.authenticatedUserID(r); id != 0 && !data.IsAuthor {
+		data.IsFollowing, err = app.users.IsFollowing(id, user.Id)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	app.renderTemplate(w, r, http.StatusOK, "profile.html", data)
+}
+
+func (app *application) userFollowers(w http.ResponseWriter, r *http.Request) {
+	app.renderFollowList(w, r, "followers", app.users.Followers)
+}
+
+func (app *application) userFollowing(w http.ResponseWriter, r *http.Request) {
+	app.renderFollowList(w, r, "following", app.users.Following)
+}
+
+// renderFollowList lists the users that list returns for the user named
+// in the URL.
+func (app *application) renderFollowList(w http.ResponseWriter, r *http.Request, name string, list func(int) ([]*models.User, error)) {
+	user := app.profileUser(w, r)
+	if user == nil {
+		return
+	}
+
+	users, err := list(user.Id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Profile = user
+	data.FollowList = name
+	data.Users = users
+	app.renderTemplate(w, r, http.StatusOK, "follow_list.html", data)
+}
+
+func (app *application) userFollowPost(w http.ResponseWriter, r *http.Request) {
+	app.setFollowing(w, r, true)
+}
+
+func (app *application) userUnfollowPost(w http.ResponseWriter, r *http.Request) {
+	app.setFollowing(w, r, false)
+}
+
+// setFollowing makes the logged in user follow or stop following the user
+// named in the URL.
+func (app *application) setFollowing(w http.ResponseWriter, r *http.Request, follow bool) {
+	user := app.profileUser(w, r)
+	if user == nil {
+		return
+	}
+
+	id := app.authenticatedUserID(r)
+
+	// Following someone again doesn't notify them again.
+	already, err := app.users.IsFollowing(id, user.Id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if follow {
+		err = app.users.Follow(id, user.Id)
+	} else {
+		err = app.users.Unfollow(id, user.Id)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrSelfFollow) {
+			app.clientError(w, r, http.StatusBadRequest)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if follow && !already {
+		app.notify(r, &models.Notification{UserId: user.Id, Kind: models.NotificationFollow})
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/u/%s", user.Username), http.StatusSeeOther)
+}
+
+// timeline shows the posts of the authors the user follows, newest first.
+// Older pages are linked with ?before=<id>, the ID of the last post shown.
+func (app *application) timeline(w http.ResponseWriter, r *http.Request) {
+	before := 0
+
+	if v := r.URL.Query().Get("before"); v != "" {
+		var err error
+		before, err = strconv.Atoi(v)
+		if err != nil || before < 1 {
+			app.clientError(w, r, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// One extra post tells whether there is an older page.
+	posts, err := app.posts.Timeline(app.authenticatedUserID(r), before, timelinePageSize+1)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+
+	if len(posts) > timelinePageSize {
+		posts = posts[:timelinePageSize]
+		data.OlderThan = posts[len(posts)-1].Id
+	}
+
+	data.Posts = posts
+	app.renderTemplate(w, r, http.StatusOK, "timeline.html", data)
+}
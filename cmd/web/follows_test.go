@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestFollow(t *testing.T) {
+	app := newTestApplication(t)
+	alice := newTestServer(t, app.routes())
+	reader := newTestServer(t, app.routes())
+
+	alice.login(t, app, "alice", "pa$$word")
+
+	assert.NilError(t, app.users.Insert("dave", "dave@example.com", "pa$$word"))
+	dave, err := app.users.GetByUsername("dave")
+	assert.NilError(t, err)
+
+	newPost(t, app, dave.Id, "Dave's post", "Hello from Dave", models.PostStatusPublished, models.PostVisibilityPublic)
+	newPost(t, app, 0, "Someone else's post", "Not followed", models.PostStatusPublished, models.PostVisibilityPublic)
+
+	_, _, body := alice.get(t, "/following")
+	assert.StringContains(t, body, "Posts by the authors you follow will show up here")
+
+	_, _, body = alice.get(t, "/u/dave")
+	assert.StringContains(t, body, "<h1>dave</h1>")
+	assert.StringContains(t, body, "0 followers")
+	assert.StringContains(t, body, "Dave&#39;s post")
+	assert.StringContains(t, body, `action="/u/dave/follow"`)
+
+	form := url.Values{}
+	form.Add("csrf_token", extractCSRFToken(t, body))
+
+	code, header, _ := alice.postForm(t, "/u/dave/follow", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/u/dave")
+
+	_, _, body = reader.get(t, "/u/dave")
+	assert.StringContains(t, body, "1 follower")
+	assert.Equal(t, strings.Contains(body, `action="/u/dave/follow"`), false)
+
+	_, _, body = reader.get(t, "/u/dave/followers")
+	assert.StringContains(t, body, `<a href="/u/alice">alice</a>`)
+
+	_, _, body = reader.get(t, "/u/alice/following")
+	assert.StringContains(t, body, `<a href="/u/dave">dave</a>`)
+
+	_, _, body = alice.get(t, "/following")
+	assert.StringContains(t, body, "Dave&#39;s post")
+	assert.Equal(t, strings.Contains(body, "Someone else"), false)
+
+	_, _, body = alice.get(t, "/u/alice")
+	assert.Equal(t, strings.Contains(body, "/u/alice/follow\""), false)
+
+	code, _, _ = alice.postForm(t, "/u/alice/follow", form)
+	assert.Equal(t, code, http.StatusBadRequest)
+
+	code, _, _ = alice.postForm(t, "/u/dave/unfollow", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = alice.get(t, "/following")
+	assert.Equal(t, strings.Contains(body, "Dave&#39;s post"), false)
+
+	code, _, _ = reader.get(t, "/u/nobody")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	code, header, _ = reader.get(t, "/following")
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/user/login")
+}
+
+func TestTimelinePagination(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	ts.login(t, app, "alice", "pa$$word")
+
+	assert.NilError(t, app.users.Insert("dave", "dave@example.com", "pa$$word"))
+	dave, err := app.users.GetByUsername("dave")
+	assert.NilError(t, err)
+
+	alice, err := app.users.GetByUsername("alice")
+	assert.NilError(t, err)
+	assert.NilError(t, app.users.Follow(alice.Id, dave.Id))
+
+	for i := 1; i <= timelinePageSize+5; i++ {
+		newPost(t, app, dave.Id, fmt.Sprintf("Post %d.", i), "Content", models.PostStatusPublished, models.PostVisibilityPublic)
+	}
+
+	_, _, body := ts.get(t, "/following")
+	assert.StringContains(t, body, fmt.Sprintf("Post %d.", timelinePageSize+5))
+	assert.StringContains(t, body, "Post 6.")
+	assert.Equal(t, strings.Contains(body, "Post 5."), false)
+	assert.StringContains(t, body, `<a href="/following?before=6">`)
+
+	_, _, body = ts.get(t, "/following?before=6")
+	assert.StringContains(t, body, "Post 5.")
+	assert.StringContains(t, body, "Post 1.")
+	assert.Equal(t, strings.Contains(body, "Post 6."), false)
+	assert.Equal(t, strings.Contains(body, "?before="), false)
+
+	code, _, _ := ts.get(t, "/following?before=abc")
+	assert.Equal(t, code, http.StatusBadRequest)
+}
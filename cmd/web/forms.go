@@ -1,18 +1,107 @@
 package main
 
-import "github.com/anxxuj/microblog/internal/validator"
+import (
+	"slices"
+	"strconv"
+	"time"
 
+	"github.com/anxxuj/microblog/internal/i18n"
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/anxxuj/microblog/internal/spam"
+	"github.com/anxxuj/microblog/internal/validator"
+)
+
+// postForm holds a new or edited post. Password is the passphrase of a
+// password-protected post; when editing a post that already has one it may
+// be left empty to keep it, which HasPassword records.
+//
+// Series is the ID of the series the post belongs to, empty for none or
+// newSeries to start the series named by NewSeries. Part is the post's
+// position in the series; empty adds it after the last part.
+// SeriesOptions are the author's series offered on the form.
 type postForm struct {
-	Name    string
-	Title   string
-	Content string
+	Name          string
+	Title         string
+	Content       string
+	Visibility    string
+	Password      string
+	HasPassword   bool
+	Series        string
+	NewSeries     string
+	Part          string
+	SeriesOptions []*models.Series
+	// MetaTitle, MetaDescription and MetaImage override what search
+	// engines and link previews show for the post.
+	MetaTitle       string
+	MetaDescription string
+	MetaImage       string
+	// Excerpt and CoverImage are shown on the post's card in listings.
+	Excerpt    string
+	CoverImage string
 	validator.Validator
 }
 
+// newSeries is the value of the series field that starts a new series.
+const newSeries = "new"
+
+func (form *postForm) meta() models.PostMeta {
+	return models.PostMeta{Title: form.MetaTitle, Description: form.MetaDescription, Image: form.MetaImage}
+}
+
 func (form *postForm) Validate() bool {
 	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be empty")
 	form.CheckField(validator.MaxChars(form.Title, 140), "title", "This field cannot be more than 140 characters long")
 	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be empty")
+	form.CheckField(validator.PermittedValue(form.Visibility, models.PostVisibilities()...), "visibility", "Choose a visibility")
+	if form.Visibility == models.PostVisibilityProtected && !form.HasPassword {
+		form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be empty")
+	}
+
+	series := []string{"", newSeries}
+	for _, s := range form.SeriesOptions {
+		series = append(series, strconv.Itoa(s.Id))
+	}
+
+	form.CheckField(validator.PermittedValue(form.Series, series...), "series", "Choose one of your series")
+	if form.Series == newSeries {
+		form.CheckField(validator.NotBlank(form.NewSeries), "newSeries", "This field cannot be empty")
+		form.CheckField(validator.MaxChars(form.NewSeries, 140), "newSeries", "This field cannot be more than 140 characters long")
+	}
+	if form.Part != "" {
+		form.CheckField(validator.IsPositiveInt(form.Part), "part", "This field must be a whole number greater than zero")
+	}
+
+	form.CheckField(validator.MaxChars(form.Excerpt, 300), "excerpt", "This field cannot be more than 300 characters long")
+	if form.CoverImage != "" {
+		form.CheckField(validator.MaxChars(form.CoverImage, 2048) && validator.IsHTTPURL(form.CoverImage), "coverImage", "This field must be a full http or https URL")
+	}
+
+	form.CheckField(validator.MaxChars(form.MetaTitle, 140), "metaTitle", "This field cannot be more than 140 characters long")
+	form.CheckField(validator.MaxChars(form.MetaDescription, 300), "metaDescription", "This field cannot be more than 300 characters long")
+	if form.MetaImage != "" {
+		form.CheckField(validator.MaxChars(form.MetaImage, 2048) && validator.IsHTTPURL(form.MetaImage), "metaImage", "This field must be a full http or https URL")
+	}
+
+	return form.Valid()
+}
+
+type postUnlockForm struct {
+	Password string
+	validator.Validator
+}
+
+// reviewForm is a reviewer's decision on a post submitted for review.
+type reviewForm struct {
+	Action string
+	Note   string
+	validator.Validator
+}
+
+func (form *reviewForm) Validate() bool {
+	form.CheckField(validator.PermittedValue(form.Action, models.ReviewApprove, models.ReviewRequestChanges), "action", "Choose an action")
+	if form.Action == models.ReviewRequestChanges {
+		form.CheckField(validator.NotBlank(form.Note), "note", "Explain what should be changed")
+	}
 
 	return form.Valid()
 }
@@ -30,6 +119,7 @@ func (form *registerForm) Validate() bool {
 	form.CheckField(validator.Matches(form.Username, validator.UsernameRX), "username", "This field must be a valid username")
 	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be empty")
 	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	form.CheckField(!spam.DisposableEmail(form.Email), "email", "Disposable email addresses are not allowed")
 	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be empty")
 	form.CheckField(validator.MinChars(form.Password, 8), "password", "This field must be atleast 8 characters long")
 	form.CheckField(validator.NotBlank(form.ConfirmPassword), "confirmPassword", "This field cannot be empty")
@@ -51,3 +141,105 @@ func (form *loginForm) Validate() bool {
 
 	return form.Valid()
 }
+
+// settingsForm holds the user's language and time zone preferences. Empty
+// values mean the browser's language and UTC. Muted are the kinds of
+// notifications the user has turned off; the form posts the ones left on.
+type settingsForm struct {
+	Language string
+	TimeZone string
+	Muted    []string
+	validator.Validator
+}
+
+// mutedNotifications returns the kinds of notifications not in notify.
+func mutedNotifications(notify []string) []string {
+	muted := []string{}
+	for _, kind := range models.NotificationKinds() {
+		if !slices.Contains(notify, kind) {
+			muted = append(muted, kind)
+		}
+	}
+	return muted
+}
+
+func (form *settingsForm) Validate() bool {
+	form.CheckField(form.Language == "" || i18n.Supported(form.Language), "language", "Choose a supported language")
+	form.CheckField(form.TimeZone == "" || validator.IsTimeZone(form.TimeZone), "timeZone", "This field must be a time zone such as Europe/Berlin")
+
+	return form.Valid()
+}
+
+func (form *settingsForm) Notifies(kind string) bool {
+	return !slices.Contains(form.Muted, kind)
+}
+
+type adminPasswordForm struct {
+	Password string
+	validator.Validator
+}
+
+func (form *adminPasswordForm) Validate() bool {
+	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be empty")
+	form.CheckField(validator.MinChars(form.Password, 8), "password", "This field must be atleast 8 characters long")
+
+	return form.Valid()
+}
+
+type adminPostsForm struct {
+	Status string
+	Action string
+	Ids    []int
+	Author string
+	validator.Validator
+}
+
+var adminPostActions = []string{"publish", "unpublish", "delete", "reassign"}
+
+func (form *adminPostsForm) Validate() bool {
+	form.CheckField(len(form.Ids) > 0, "ids", "Select at least one post")
+	form.CheckField(validator.PermittedValue(form.Action, adminPostActions...), "action", "Choose an action")
+	if form.Action == "reassign" {
+		form.CheckField(validator.NotBlank(form.Author), "author", "This field cannot be empty")
+	}
+
+	return form.Valid()
+}
+
+func (form *adminPostsForm) Selected(id int) bool {
+	return slices.Contains(form.Ids, id)
+}
+
+// auditFilterForm holds the filters of the audit log page. Since and Until
+// are dates; Until is inclusive.
+type auditFilterForm struct {
+	Action string
+	Actor  string
+	Since  string
+	Until  string
+	validator.Validator
+}
+
+func (form *auditFilterForm) Validate() bool {
+	form.CheckField(form.Action == "" || validator.PermittedValue(form.Action, models.AuditActions()...), "action", "Choose a valid action")
+	form.CheckField(form.Since == "" || validator.IsDate(form.Since), "since", "This field must be a date (YYYY-MM-DD)")
+	form.CheckField(form.Until == "" || validator.IsDate(form.Until), "until", "This field must be a date (YYYY-MM-DD)")
+
+	return form.Valid()
+}
+
+// Filter converts the form, which must be valid, into a filter for the
+// audit log model.
+func (form *auditFilterForm) Filter(limit int) models.AuditFilter {
+	filter := models.AuditFilter{Action: form.Action, Actor: form.Actor, Limit: limit}
+
+	if form.Since != "" {
+		filter.Since, _ = time.Parse(time.DateOnly, form.Since)
+	}
+	if form.Until != "" {
+		until, _ := time.Parse(time.DateOnly, form.Until)
+		filter.Until = until.AddDate(0, 0, 1)
+	}
+
+	return filter
+}
@@ -1,25 +1,44 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
+	"github.com/anxxuj/microblog/internal/backup"
+	"github.com/anxxuj/microblog/internal/i18n"
 	"github.com/anxxuj/microblog/internal/models"
 	"github.com/julienschmidt/httprouter"
 )
 
 func (app *application) index(w http.ResponseWriter, r *http.Request) {
-	posts, err := app.posts.GetAll()
+	sort := r.URL.Query().Get("sort")
+
+	posts, err := app.posts.Latest(sort)
 	if err != nil {
-		app.serverError(w, err)
+		if errors.Is(err, models.ErrInvalidSort) {
+			app.clientError(w, r, http.StatusBadRequest)
+		} else {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
+	if sort == "" {
+		sort = models.SortNewest
+	}
+
 	data := app.newTemplateData(r)
 	data.Posts = posts
-	app.renderTemplate(w, http.StatusOK, "index.html", data)
+	data.Sort = sort
+	app.renderTemplate(w, r, http.StatusOK, "index.html", data)
 }
 
 func (app *application) postView(w http.ResponseWriter, r *http.Request) {
@@ -27,149 +46,667 @@ func (app *application) postView(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.Atoi(params.ByName("id"))
 	if err != nil || id < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
 		return
 	}
 
 	post, err := app.posts.Get(id)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.notFound(w, r)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
 
+	if !app.canView(r, post) {
+		app.notFound(w, r)
+		return
+	}
+
+	// Posts that aren't public must not end up in shared caches or search
+	// engines.
+	if post.Visibility != models.PostVisibilityPublic {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Robots-Tag", "noindex")
+	}
+
 	data := app.newTemplateData(r)
 	data.Post = post
-	app.renderTemplate(w, http.StatusOK, "post.html", data)
+	data.IsAuthor = post.UserId != 0 && post.UserId == app.authenticatedUserID(r)
+	data.CanEdit = app.canEdit(r, post)
+
+	if app.isLocked(r, post) {
+		data.Form = &postUnlockForm{}
+		app.renderTemplate(w, r, http.StatusOK, "post_unlock.html", data)
+		return
+	}
+
+	data.Meta = app.postMeta(r, post)
+
+	data.Reactions, err = app.posts.Reactions(post.Id, app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data.SeriesNav, err = app.newSeriesNav(r, post)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Authors see the reviewers' notes on posts that aren't published yet.
+	if data.IsAuthor && post.Status != models.PostStatusPublished {
+		data.Reviews, err = app.posts.Reviews(post.Id)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	app.renderTemplate(w, r, http.StatusOK, "post.html", data)
+}
+
+// canView reports whether the post may be shown to the user making the
+// request. Unpublished posts are only visible to their author and admins,
+// and posts in review also to reviewers. Private posts are only visible to
+// their author and users who may view private posts. Password-protected
+// posts can be viewed, but see isLocked.
+func (app *application) canView(r *http.Request, post *models.Post) bool {
+	user := app.authenticatedUser(r)
+	isAuthor := user != nil && user.Id == post.UserId
+
+	if post.Status != models.PostStatusPublished && !isAuthor && !user.Can(models.PermissionAdmin) &&
+		!(post.Status == models.PostStatusInReview && user.Can(models.PermissionReview)) {
+		return false
+	}
+
+	if post.Visibility == models.PostVisibilityPrivate && !isAuthor && !user.Can(models.PermissionViewPrivate) {
+		return false
+	}
+
+	return true
+}
+
+// canEdit reports whether the user making the request may edit, delete
+// and restore the post: its author and admins.
+func (app *application) canEdit(r *http.Request, post *models.Post) bool {
+	user := app.authenticatedUser(r)
+	return user != nil && (user.Id == post.UserId || user.Can(models.PermissionAdmin))
+}
+
+// editablePost returns the post named by the :id parameter if the logged
+// in user may edit it. Otherwise it writes a not found response if they
+// can't see the post, or a forbidden one if they can, and returns nil.
+func (app *application) editablePost(w http.ResponseWriter, r *http.Request) *models.Post {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return nil
+	}
+
+	post, err := app.posts.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return nil
+	}
+
+	if !app.canView(r, post) {
+		app.notFound(w, r)
+		return nil
+	}
+
+	if !app.canEdit(r, post) {
+		app.clientError(w, r, http.StatusForbidden)
+		return nil
+	}
+
+	return post
+}
+
+// isLocked reports whether the post is password-protected and the reader
+// hasn't entered its passphrase in this session. Authors and users who may
+// view private posts don't need the passphrase.
+func (app *application) isLocked(r *http.Request, post *models.Post) bool {
+	if post.Visibility != models.PostVisibilityProtected {
+		return false
+	}
+
+	user := app.authenticatedUser(r)
+	if (user != nil && user.Id == post.UserId) || user.Can(models.PermissionViewPrivate) {
+		return false
+	}
+
+	unlocked := app.sessionManager.GetString(r.Context(), unlockedPostKey(post.Id))
+
+	return len(post.PasswordHash) == 0 || unlocked != passwordFingerprint(post)
+}
+
+// unlockedPostKey is the session key under which an unlocked post is
+// remembered.
+func unlockedPostKey(id int) string {
+	return fmt.Sprintf("unlockedPost:%d", id)
+}
+
+// passwordFingerprint identifies the current passphrase of a post without
+// storing its hash in the session, so changing the passphrase locks the
+// post again for everyone.
+func passwordFingerprint(post *models.Post) string {
+	sum := sha256.Sum256(post.PasswordHash)
+	return hex.EncodeToString(sum[:])
+}
+
+func (app *application) postUnlockPost(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	post, err := app.posts.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if !app.canView(r, post) {
+		app.notFound(w, r)
+		return
+	}
+
+	form := &postUnlockForm{Password: r.PostForm.Get("password")}
+
+	if app.isLocked(r, post) {
+		if !post.CheckPassword(form.Password) {
+			form.AddFieldError("password", "The passphrase is incorrect")
+
+			w.Header().Set("Cache-Control", "no-store")
+
+			data := app.newTemplateData(r)
+			data.Post = post
+			data.Form = form
+			app.renderTemplate(w, r, http.StatusUnprocessableEntity, "post_unlock.html", data)
+			return
+		}
+
+		app.sessionManager.Put(r.Context(), unlockedPostKey(post.Id), passwordFingerprint(post))
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/view/%d", id), http.StatusSeeOther)
+}
+
+// postBySlug redirects the permalink of an imported post to its canonical
+// URL, so links to its original location keep working.
+func (app *application) postBySlug(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	post, err := app.posts.GetBySlug(params.ByName("slug"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if !app.canView(r, post) {
+		app.notFound(w, r)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/view/%d", post.Id), http.StatusMovedPermanently)
 }
 
 func (app *application) postAdd(w http.ResponseWriter, r *http.Request) {
+	options, err := app.seriesOptions(app.authenticatedUserID(r), 0)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.startForm(r, "post")
+
 	data := app.newTemplateData(r)
-	data.Form = &postForm{Name: "Add Post"}
-	app.renderTemplate(w, http.StatusOK, "post_form.html", data)
+	data.Form = &postForm{Name: "Add Post", Visibility: models.PostVisibilityPublic, SeriesOptions: options}
+	app.renderTemplate(w, r, http.StatusOK, "post_form.html", data)
 }
 
 func (app *application) postAddPost(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	options, err := app.seriesOptions(app.authenticatedUserID(r), 0)
+	if err != nil {
+		app.serverError(w, r, err)
 		return
 	}
 
 	form := &postForm{
-		Name:    "Add Post",
-		Title:   r.PostForm.Get("title"),
-		Content: r.PostForm.Get("content"),
+		Name:          "Add Post",
+		Title:         r.PostForm.Get("title"),
+		Content:       r.PostForm.Get("content"),
+		Visibility:    r.PostForm.Get("visibility"),
+		Password:      r.PostForm.Get("password"),
+		Series:        r.PostForm.Get("series"),
+		NewSeries:     r.PostForm.Get("newSeries"),
+		Part:          r.PostForm.Get("part"),
+		SeriesOptions: options,
+
+		MetaTitle:       r.PostForm.Get("metaTitle"),
+		MetaDescription: r.PostForm.Get("metaDescription"),
+		MetaImage:       r.PostForm.Get("metaImage"),
+
+		Excerpt:    r.PostForm.Get("excerpt"),
+		CoverImage: r.PostForm.Get("coverImage"),
+	}
+
+	if form.Visibility == "" {
+		form.Visibility = models.PostVisibilityPublic
+	}
+
+	if !app.looksHuman(r, "post") {
+		form.AddNonFieldError("Please check your post and submit it again")
 	}
 
 	if !form.Validate() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.renderTemplate(w, http.StatusUnprocessableEntity, "post_form.html", data)
+		app.renderTemplate(w, r, http.StatusUnprocessableEntity, "post_form.html", data)
 		return
 	}
 
-	id, err := app.posts.Insert(form.Title, form.Content)
+	limited, err := app.postRateLimited(r)
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
-	app.sessionManager.Put(r.Context(), "flash", "Post created successfully")
+	if limited {
+		form.AddNonFieldError("You have written too many posts recently, please try again later")
 
-	http.Redirect(w, r, fmt.Sprintf("/post/view/%d", id), http.StatusSeeOther)
+		data := app.newTemplateData(r)
+		data.Form = form
+		app.renderTemplate(w, r, http.StatusTooManyRequests, "post_form.html", data)
+		return
+	}
+
+	verdict := app.classifyPost(r, form.Title, form.Content)
+
+	// Posts that need review are created as drafts and submitted straight
+	// away, so that the submission shows in their review history.
+	status, action := models.PostStatusPublished, ""
+	if verdict.Spam {
+		status = models.PostStatusFlagged
+	} else if app.needsReview(r) {
+		status, action = models.PostStatusDraft, models.ReviewSubmit
+	}
+
+	seriesId, part, series := formSeries(form)
+
+	post := &models.Post{
+		UserId:     app.authenticatedUserID(r),
+		Title:      form.Title,
+		Content:    form.Content,
+		Status:     status,
+		Visibility: form.Visibility,
+		SeriesId:   seriesId,
+		SeriesPart: part,
+		Meta:       form.meta(),
+		Excerpt:    form.Excerpt,
+		CoverImage: form.CoverImage,
+	}
+
+	password := ""
+	if form.Visibility == models.PostVisibilityProtected {
+		password = form.Password
+	}
+
+	review, err := app.posts.Save(post, series, app.authenticatedUserID(r), password, action)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.auditSeries(r, series)
+	app.notifyMentions(r, post.Id)
+
+	app.finishForm(r, "post")
+
+	after := newPostAudit(post)
+	after.Status, after.Spam = status, verdict.Reasons
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditPostCreate, TargetType: "post", TargetId: post.Id}, nil, after)
+
+	if review != nil {
+		app.auditReview(r, review)
+	}
+
+	switch post.Status {
+	case models.PostStatusFlagged:
+		app.sessionManager.Put(r.Context(), "flash", "Your post is awaiting review by a moderator")
+	case models.PostStatusInReview:
+		app.sessionManager.Put(r.Context(), "flash", "Your post has been submitted for review")
+	default:
+		app.sessionManager.Put(r.Context(), "flash", "Post created successfully")
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/view/%d", post.Id), http.StatusSeeOther)
 }
 
 func (app *application) postEdit(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
-
-	id, err := strconv.Atoi(params.ByName("id"))
-	if err != nil || id < 1 {
-		app.notFound(w)
+	post := app.editablePost(w, r)
+	if post == nil {
 		return
 	}
 
-	post, err := app.posts.Get(id)
+	options, err := app.seriesOptions(post.UserId, post.SeriesId)
 	if err != nil {
-		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
-		} else {
-			app.serverError(w, err)
-		}
+		app.serverError(w, r, err)
 		return
 	}
 
 	form := &postForm{
-		Name:    "Edit Post",
-		Title:   post.Title,
-		Content: post.Content,
+		Name:          "Edit Post",
+		Title:         post.Title,
+		Content:       post.Content,
+		Visibility:    post.Visibility,
+		HasPassword:   len(post.PasswordHash) > 0,
+		SeriesOptions: options,
+
+		MetaTitle:       post.Meta.Title,
+		MetaDescription: post.Meta.Description,
+		MetaImage:       post.Meta.Image,
+
+		Excerpt:    post.Excerpt,
+		CoverImage: post.CoverImage,
+	}
+
+	if post.SeriesId != 0 {
+		form.Series = strconv.Itoa(post.SeriesId)
+		form.Part = strconv.Itoa(post.SeriesPart)
 	}
 
+	app.startForm(r, "post")
+
 	data := app.newTemplateData(r)
 	data.Form = form
-	app.renderTemplate(w, http.StatusOK, "post_form.html", data)
+	app.renderTemplate(w, r, http.StatusOK, "post_form.html", data)
 }
 
 func (app *application) postEditPost(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
+	before := app.editablePost(w, r)
+	if before == nil {
+		return
+	}
+	id := before.Id
 
-	id, err := strconv.Atoi(params.ByName("id"))
-	if err != nil || id < 1 {
-		app.notFound(w)
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
 		return
 	}
 
-	err = r.ParseForm()
+	options, err := app.seriesOptions(before.UserId, before.SeriesId)
 	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
+		app.serverError(w, r, err)
 		return
 	}
 
 	form := &postForm{
-		Name:    "Edit Post",
-		Title:   r.PostForm.Get("title"),
-		Content: r.PostForm.Get("content"),
+		Name:          "Edit Post",
+		Title:         r.PostForm.Get("title"),
+		Content:       r.PostForm.Get("content"),
+		Visibility:    r.PostForm.Get("visibility"),
+		Password:      r.PostForm.Get("password"),
+		HasPassword:   len(before.PasswordHash) > 0,
+		Series:        r.PostForm.Get("series"),
+		NewSeries:     r.PostForm.Get("newSeries"),
+		Part:          r.PostForm.Get("part"),
+		SeriesOptions: options,
+
+		MetaTitle:       r.PostForm.Get("metaTitle"),
+		MetaDescription: r.PostForm.Get("metaDescription"),
+		MetaImage:       r.PostForm.Get("metaImage"),
+
+		Excerpt:    r.PostForm.Get("excerpt"),
+		CoverImage: r.PostForm.Get("coverImage"),
+	}
+
+	if form.Visibility == "" {
+		form.Visibility = before.Visibility
+	}
+
+	if !r.PostForm.Has("series") && before.SeriesId != 0 {
+		form.Series = strconv.Itoa(before.SeriesId)
+	}
+
+	if !r.PostForm.Has("metaTitle") && !r.PostForm.Has("metaDescription") && !r.PostForm.Has("metaImage") {
+		form.MetaTitle, form.MetaDescription, form.MetaImage = before.Meta.Title, before.Meta.Description, before.Meta.Image
+	}
+
+	if !r.PostForm.Has("excerpt") && !r.PostForm.Has("coverImage") {
+		form.Excerpt, form.CoverImage = before.Excerpt, before.CoverImage
+	}
+
+	if !app.looksHuman(r, "post") {
+		form.AddNonFieldError("Please check your post and submit it again")
 	}
 
 	if !form.Validate() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.renderTemplate(w, http.StatusUnprocessableEntity, "post_form.html", data)
+		app.renderTemplate(w, r, http.StatusUnprocessableEntity, "post_form.html", data)
 		return
 	}
 
-	err = app.posts.Update(id, form.Title, form.Content)
+	// Published posts edited into spam are taken down for review, and
+	// edits by authors whose posts need review go back to the review queue.
+	status, action := before.Status, ""
+	verdict := app.classifyPost(r, form.Title, form.Content)
+
+	if verdict.Spam && status == models.PostStatusPublished {
+		status = models.PostStatusFlagged
+	} else if status == models.PostStatusPublished && app.needsReview(r) {
+		action = models.ReviewResubmit
+	}
+
+	seriesId, part, series := formSeries(form)
+
+	post := &models.Post{
+		Id:         id,
+		UserId:     before.UserId,
+		Title:      form.Title,
+		Content:    form.Content,
+		Status:     status,
+		Visibility: form.Visibility,
+		SeriesId:   seriesId,
+		SeriesPart: part,
+		Meta:       form.meta(),
+		Excerpt:    form.Excerpt,
+		CoverImage: form.CoverImage,
+	}
+
+	password := ""
+	if form.Visibility == models.PostVisibilityProtected {
+		password = form.Password
+	}
+
+	review, err := app.posts.Save(post, series, app.authenticatedUserID(r), password, action)
 	if err != nil {
-		app.serverError(w, err)
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
-	app.sessionManager.Put(r.Context(), "flash", "Post updated successfully")
+	app.auditSeries(r, series)
+	app.notifyMentions(r, post.Id)
+
+	app.finishForm(r, "post")
+
+	after := newPostAudit(post)
+	after.Status, after.Spam = status, verdict.Reasons
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditPostUpdate, TargetType: "post", TargetId: id}, newPostAudit(before), after)
+
+	if review != nil {
+		app.auditReview(r, review)
+	}
+
+	switch {
+	case post.Status == models.PostStatusFlagged:
+		app.sessionManager.Put(r.Context(), "flash", "Your post is awaiting review by a moderator")
+	case post.Status != before.Status && post.Status == models.PostStatusInReview:
+		app.sessionManager.Put(r.Context(), "flash", "Your changes have been submitted for review")
+	default:
+		app.sessionManager.Put(r.Context(), "flash", "Post updated successfully")
+	}
 
 	http.Redirect(w, r, fmt.Sprintf("/post/view/%d", id), http.StatusSeeOther)
 }
 
-func (app *application) postDelete(w http.ResponseWriter, r *http.Request) {
+func (app *application) postDeletePost(w http.ResponseWriter, r *http.Request) {
+	post := app.editablePost(w, r)
+	if post == nil {
+		return
+	}
+	id := post.Id
+
+	err := app.posts.Delete(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditPostDelete, TargetType: "post", TargetId: id}, newPostAudit(post), nil)
+
+	app.sessionManager.Put(r.Context(), "flash", "Post moved to trash")
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// trashOwner returns the ID of the user whose trash the logged in user
+// sees: their own, or 0 for admins, who see every user's.
+func (app *application) trashOwner(r *http.Request) int {
+	if app.authenticatedUser(r).Can(models.PermissionAdmin) {
+		return 0
+	}
+	return app.authenticatedUserID(r)
+}
+
+// trashedPost returns the ID of the post named by the :id parameter if it
+// is in the logged in user's trash. Otherwise it writes a not found
+// response and returns 0.
+func (app *application) trashedPost(w http.ResponseWriter, r *http.Request) int {
 	params := httprouter.ParamsFromContext(r.Context())
 
 	id, err := strconv.Atoi(params.ByName("id"))
 	if err != nil || id < 1 {
-		app.notFound(w)
+		app.notFound(w, r)
+		return 0
+	}
+
+	posts, err := app.posts.GetDeleted(app.trashOwner(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return 0
+	}
+
+	if !slices.ContainsFunc(posts, func(p *models.Post) bool { return p.Id == id }) {
+		app.notFound(w, r)
+		return 0
+	}
+
+	return id
+}
+
+func (app *application) trash(w http.ResponseWriter, r *http.Request) {
+	posts, err := app.posts.GetDeleted(app.trashOwner(r))
+	if err != nil {
+		app.serverError(w, r, err)
 		return
 	}
 
-	err = app.posts.Delete(id)
+	data := app.newTemplateData(r)
+	data.Posts = posts
+	data.TrashRetention = app.config.TrashRetention
+	app.renderTemplate(w, r, http.StatusOK, "trash.html", data)
+}
+
+func (app *application) trashRestorePost(w http.ResponseWriter, r *http.Request) {
+	id := app.trashedPost(w, r)
+	if id == 0 {
+		return
+	}
+
+	err := app.posts.Restore(id)
 	if err != nil {
-		app.serverError(w, err)
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
-	app.sessionManager.Put(r.Context(), "flash", "Post deleted successfully")
+	app.audit(r, &models.AuditEvent{Action: models.AuditPostRestore, TargetType: "post", TargetId: id}, nil, nil)
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	app.sessionManager.Put(r.Context(), "flash", "Post restored successfully")
+
+	http.Redirect(w, r, fmt.Sprintf("/post/view/%d", id), http.StatusSeeOther)
+}
+
+func (app *application) trashDeletePost(w http.ResponseWriter, r *http.Request) {
+	id := app.trashedPost(w, r)
+	if id == 0 {
+		return
+	}
+
+	err := app.posts.DeletePermanently(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditPostPurge, TargetType: "post", TargetId: id}, nil, nil)
+
+	app.sessionManager.Put(r.Context(), "flash", "Post deleted permanently")
+
+	http.Redirect(w, r, "/trash", http.StatusSeeOther)
 }
 
 func (app *application) userRegister(w http.ResponseWriter, r *http.Request) {
@@ -178,9 +715,11 @@ func (app *application) userRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.startForm(r, "register")
+
 	data := app.newTemplateData(r)
 	data.Form = &registerForm{}
-	app.renderTemplate(w, http.StatusOK, "register.html", data)
+	app.renderTemplate(w, r, http.StatusOK, "register.html", data)
 }
 
 func (app *application) userRegisterPost(w http.ResponseWriter, r *http.Request) {
@@ -191,7 +730,7 @@ func (app *application) userRegisterPost(w http.ResponseWriter, r *http.Request)
 
 	err := r.ParseForm()
 	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
+		app.clientError(w, r, http.StatusBadRequest)
 		return
 	}
 
@@ -202,10 +741,14 @@ func (app *application) userRegisterPost(w http.ResponseWriter, r *http.Request)
 		ConfirmPassword: r.PostForm.Get("confirm-password"),
 	}
 
+	if !app.looksHuman(r, "register") {
+		form.AddNonFieldError("Please check your details and submit the form again")
+	}
+
 	if !form.Validate() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.renderTemplate(w, http.StatusUnprocessableEntity, "register.html", data)
+		app.renderTemplate(w, r, http.StatusUnprocessableEntity, "register.html", data)
 		return
 	}
 
@@ -216,20 +759,27 @@ func (app *application) userRegisterPost(w http.ResponseWriter, r *http.Request)
 
 			data := app.newTemplateData(r)
 			data.Form = form
-			app.renderTemplate(w, http.StatusUnprocessableEntity, "register.html", data)
+			app.renderTemplate(w, r, http.StatusUnprocessableEntity, "register.html", data)
 		} else if errors.Is(err, models.ErrDuplicateEmail) {
 			form.AddFieldError("email", "Email address is already in use")
 
 			data := app.newTemplateData(r)
 			data.Form = form
-			app.renderTemplate(w, http.StatusUnprocessableEntity, "register.html", data)
+			app.renderTemplate(w, r, http.StatusUnprocessableEntity, "register.html", data)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 
 		return
 	}
 
+	app.finishForm(r, "register")
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditRegister, TargetType: "user"}, nil, map[string]string{
+		"username": form.Username,
+		"email":    form.Email,
+	})
+
 	app.sessionManager.Put(r.Context(), "flash", "User registered successfully")
 
 	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
@@ -243,7 +793,7 @@ func (app *application) userLogin(w http.ResponseWriter, r *http.Request) {
 
 	data := app.newTemplateData(r)
 	data.Form = &loginForm{}
-	app.renderTemplate(w, http.StatusOK, "login.html", data)
+	app.renderTemplate(w, r, http.StatusOK, "login.html", data)
 }
 
 func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
@@ -254,7 +804,7 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 
 	err := r.ParseForm()
 	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
+		app.clientError(w, r, http.StatusBadRequest)
 		return
 	}
 
@@ -266,20 +816,28 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 	if !form.Validate() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.renderTemplate(w, http.StatusUnprocessableEntity, "login.html", data)
+		app.renderTemplate(w, r, http.StatusUnprocessableEntity, "login.html", data)
 		return
 	}
 
 	id, err := app.users.Authenticate(form.Username, form.Password)
 	if err != nil {
 		if errors.Is(err, models.ErrInvalidCredentials) {
+			app.auditLoginFailed(r, form.Username, "invalid credentials")
 			form.AddNonFieldError("Username or password is incorrect")
 
 			data := app.newTemplateData(r)
 			data.Form = form
-			app.renderTemplate(w, http.StatusUnprocessableEntity, "login.html", data)
+			app.renderTemplate(w, r, http.StatusUnprocessableEntity, "login.html", data)
+		} else if errors.Is(err, models.ErrAccountDisabled) {
+			app.auditLoginFailed(r, form.Username, "account disabled")
+			form.AddNonFieldError("This account has been disabled")
+
+			data := app.newTemplateData(r)
+			data.Form = form
+			app.renderTemplate(w, r, http.StatusForbidden, "login.html", data)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 
 		return
@@ -287,25 +845,125 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 
 	err = app.sessionManager.RenewToken(r.Context())
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
 	app.sessionManager.Put(r.Context(), "authenticatedUserID", id)
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditLogin, ActorId: id, Actor: form.Username}, nil, nil)
 	app.sessionManager.Put(r.Context(), "flash", "User logged in successfully")
 
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+func (app *application) userSettings(w http.ResponseWriter, r *http.Request) {
+	user := app.authenticatedUser(r)
+
+	muted, err := app.notifications.Muted(user.Id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Form = &settingsForm{Language: user.Language, TimeZone: user.TimeZone, Muted: muted}
+	data.Languages = i18n.Languages()
+	app.renderTemplate(w, r, http.StatusOK, "settings.html", data)
+}
+
+func (app *application) userSettingsPost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	form := &settingsForm{
+		Language: r.PostForm.Get("language"),
+		TimeZone: r.PostForm.Get("time-zone"),
+		Muted:    mutedNotifications(r.PostForm["notify"]),
+	}
+
+	if !form.Validate() {
+		data := app.newTemplateData(r)
+		data.Form = form
+		data.Languages = i18n.Languages()
+		app.renderTemplate(w, r, http.StatusUnprocessableEntity, "settings.html", data)
+		return
+	}
+
+	err = app.users.SetPreferences(app.authenticatedUserID(r), form.Language, form.TimeZone)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.notifications.SetMuted(app.authenticatedUserID(r), form.Muted)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Settings saved")
+
+	http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+}
+
+func (app *application) userExportPosts(w http.ResponseWriter, r *http.Request) {
+	posts, err := app.posts.GetByUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+
+	err = backup.WriteMarkdown(buf, posts)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="posts.zip"`)
+
+	buf.WriteTo(w)
+}
+
 func (app *application) userLogout(w http.ResponseWriter, r *http.Request) {
 	err := app.sessionManager.RenewToken(r.Context())
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
+	app.audit(r, &models.AuditEvent{Action: models.AuditLogout}, nil, nil)
+
 	app.sessionManager.Remove(r.Context(), "authenticatedUserID")
 	app.sessionManager.Put(r.Context(), "flash", "User logged out successfully")
 
 	http.Redirect(w, r, "/user/login", http.StatusSeeOther)
 }
+
+func (app *application) healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+func (app *application) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	err := app.db.PingContext(ctx)
+	if err != nil {
+		app.logger.Warn("readiness check failed", "error", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("database unavailable\n"))
+		return
+	}
+
+	w.Write([]byte("ok\n"))
+}
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestHealthz(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/healthz")
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, body, "ok")
+}
+
+func TestPostView(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	newPost(t, app, 1, "An old silent pond", "A frog jumps into the pond", models.PostStatusPublished, models.PostVisibilityPublic)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{"Valid ID", "/post/view/1", http.StatusOK, "A frog jumps into the pond"},
+		{"Non-existent ID", "/post/view/2", http.StatusNotFound, ""},
+		{"Negative ID", "/post/view/-1", http.StatusNotFound, ""},
+		{"Decimal ID", "/post/view/1.23", http.StatusNotFound, ""},
+		{"String ID", "/post/view/foo", http.StatusNotFound, ""},
+		{"Empty ID", "/post/view/", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestUserRegisterPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	err := app.users.Insert("alice", "alice@example.com", "pa$$word")
+	assert.NilError(t, err)
+
+	validCSRFToken := ts.csrfToken(t, "/user/register")
+
+	const (
+		validUsername = "bobby"
+		validEmail    = "bob@example.com"
+		validPassword = "validPa$$word"
+		formTag       = `<form action="" method="post" novalidate>`
+	)
+
+	tests := []struct {
+		name            string
+		username        string
+		email           string
+		password        string
+		confirmPassword string
+		csrfToken       string
+		wantCode        int
+		wantFormTag     string
+	}{
+		{"Valid submission", "carol", validEmail, validPassword, validPassword, validCSRFToken, http.StatusSeeOther, ""},
+		{"Invalid CSRF token", validUsername, validEmail, validPassword, validPassword, "wrongToken", http.StatusBadRequest, ""},
+		{"Empty username", "", validEmail, validPassword, validPassword, validCSRFToken, http.StatusUnprocessableEntity, formTag},
+		{"Invalid username", "1bobby", validEmail, validPassword, validPassword, validCSRFToken, http.StatusUnprocessableEntity, formTag},
+		{"Empty email", validUsername, "", validPassword, validPassword, validCSRFToken, http.StatusUnprocessableEntity, formTag},
+		{"Invalid email", validUsername, "bob@example.", validPassword, validPassword, validCSRFToken, http.StatusUnprocessableEntity, formTag},
+		{"Short password", validUsername, validEmail, "pa$$", "pa$$", validCSRFToken, http.StatusUnprocessableEntity, formTag},
+		{"Mismatched passwords", validUsername, validEmail, validPassword, "other", validCSRFToken, http.StatusUnprocessableEntity, formTag},
+		{"Duplicate username", "alice", validEmail, validPassword, validPassword, validCSRFToken, http.StatusUnprocessableEntity, "Username is already in use"},
+		{"Duplicate email", validUsername, "alice@example.com", validPassword, validPassword, validCSRFToken, http.StatusUnprocessableEntity, "Email address is already in use"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("username", tt.username)
+			form.Add("email", tt.email)
+			form.Add("password", tt.password)
+			form.Add("confirm-password", tt.confirmPassword)
+			form.Add("csrf_token", tt.csrfToken)
+
+			code, _, body := ts.postForm(t, "/user/register", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantFormTag)
+		})
+	}
+}
+
+func TestUserLoginPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	err := app.users.Insert("alice", "alice@example.com", "pa$$word")
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name         string
+		username     string
+		password     string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{"Wrong password", "alice", "wrong", http.StatusUnprocessableEntity, "", "Username or password is incorrect"},
+		{"Unknown user", "nobody", "pa$$word", http.StatusUnprocessableEntity, "", "Username or password is incorrect"},
+		{"Empty password", "alice", "", http.StatusUnprocessableEntity, "", "This field cannot be empty"},
+		{"Valid credentials", "alice", "pa$$word", http.StatusSeeOther, "/", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("username", tt.username)
+			form.Add("password", tt.password)
+			form.Add("csrf_token", ts.csrfToken(t, "/user/login"))
+
+			code, header, body := ts.postForm(t, "/user/login", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+
+	code, _, body := ts.get(t, "/")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, `<a href="/user/logout">Logout</a>`)
+}
+
+func TestPostAddRequiresAuthentication(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	code, header, _ := ts.get(t, "/post/add")
+
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/user/login")
+}
+
+func TestPostLifecycle(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	ts.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("title", "")
+	form.Add("content", "Content")
+	form.Add("csrf_token", ts.csrfToken(t, "/post/add"))
+
+	code, _, body := ts.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "This field cannot be empty")
+
+	form.Set("title", "First post")
+	code, header, _ := ts.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/post/view/1")
+
+	_, _, body = ts.get(t, "/")
+	assert.StringContains(t, body, "First post")
+
+	form = url.Values{}
+	form.Add("title", "Edited post")
+	form.Add("content", "Edited content")
+	form.Add("csrf_token", ts.csrfToken(t, "/post/edit/1"))
+
+	code, _, _ = ts.postForm(t, "/post/edit/1", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, "/post/view/1")
+	assert.StringContains(t, body, "Edited content")
+
+	csrf := url.Values{}
+	csrf.Add("csrf_token", ts.csrfToken(t, "/post/view/1"))
+
+	code, _, _ = ts.get(t, "/post/delete/1")
+	assert.Equal(t, code, http.StatusMethodNotAllowed)
+
+	code, _, _ = ts.postForm(t, "/post/delete/1", csrf)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	code, _, _ = ts.get(t, "/post/view/1")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	_, _, body = ts.get(t, "/trash")
+	assert.StringContains(t, body, "Edited post")
+
+	code, header, _ = ts.postForm(t, "/trash/restore/1", csrf)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/post/view/1")
+
+	code, _, _ = ts.get(t, "/post/view/1")
+	assert.Equal(t, code, http.StatusOK)
+
+	code, _, _ = ts.postForm(t, "/trash/delete/1", csrf)
+	assert.Equal(t, code, http.StatusNotFound)
+
+	ts.postForm(t, "/post/delete/1", csrf)
+	code, _, _ = ts.postForm(t, "/trash/delete/1", csrf)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, "/trash")
+	assert.StringContains(t, body, "The trash is empty")
+}
+
+func TestPostEditPermissions(t *testing.T) {
+	app := newTestApplication(t)
+	author := newTestServer(t, app.routes())
+	other := newTestServer(t, app.routes())
+	admin := newTestServer(t, app.routes())
+
+	author.login(t, app, "alice", "pa$$word")
+	other.login(t, app, "bobby", "pa$$word")
+	admin.loginAdmin(t, app, "carol")
+
+	alice, err := app.users.GetByUsername("alice")
+	assert.NilError(t, err)
+
+	newPost(t, app, alice.Id, "Diary", "Private thoughts", models.PostStatusPublished, models.PostVisibilityPrivate)
+	newPost(t, app, alice.Id, "Hello", "Public words", models.PostStatusPublished, models.PostVisibilityPublic)
+
+	code, _, body := other.get(t, "/post/edit/1")
+	assert.Equal(t, code, http.StatusNotFound)
+	assert.Equal(t, strings.Contains(body, "Private thoughts"), false)
+
+	form := url.Values{}
+	form.Add("title", "Hijacked")
+	form.Add("content", "Hijacked")
+	form.Add("visibility", models.PostVisibilityPublic)
+	form.Add("csrf_token", other.csrfToken(t, "/post/add"))
+
+	code, _, _ = other.postForm(t, "/post/edit/1", form)
+	assert.Equal(t, code, http.StatusNotFound)
+
+	code, _, _ = other.get(t, "/post/edit/2")
+	assert.Equal(t, code, http.StatusForbidden)
+
+	code, _, _ = other.postForm(t, "/post/edit/2", form)
+	assert.Equal(t, code, http.StatusForbidden)
+
+	for _, id := range []int{1, 2} {
+		post, err := app.posts.Get(id)
+		assert.NilError(t, err)
+		assert.Equal(t, post.Title == "Hijacked", false)
+	}
+
+	_, _, body = other.get(t, "/post/view/2")
+	assert.Equal(t, strings.Contains(body, "/post/edit/2"), false)
+
+	_, _, body = admin.get(t, "/post/view/2")
+	assert.StringContains(t, body, "/post/edit/2")
+
+	form.Set("title", "Moderated")
+	form.Set("csrf_token", admin.csrfToken(t, "/post/edit/2"))
+
+	code, _, _ = admin.postForm(t, "/post/edit/2", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestPostTrashPermissions(t *testing.T) {
+	app := newTestApplication(t)
+	author := newTestServer(t, app.routes())
+	other := newTestServer(t, app.routes())
+	admin := newTestServer(t, app.routes())
+
+	author.login(t, app, "alice", "pa$$word")
+	other.login(t, app, "bobby", "pa$$word")
+	admin.loginAdmin(t, app, "carol")
+
+	alice, err := app.users.GetByUsername("alice")
+	assert.NilError(t, err)
+
+	newPost(t, app, alice.Id, "Hello", "Public words", models.PostStatusPublished, models.PostVisibilityPublic)
+
+	csrf := url.Values{}
+	csrf.Add("csrf_token", other.csrfToken(t, "/post/add"))
+
+	code, _, _ := other.postForm(t, "/post/delete/1", csrf)
+	assert.Equal(t, code, http.StatusForbidden)
+
+	_, err = app.posts.Get(1)
+	assert.NilError(t, err)
+
+	own := url.Values{}
+	own.Add("csrf_token", author.csrfToken(t, "/post/view/1"))
+
+	code, _, _ = author.postForm(t, "/post/delete/1", own)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body := other.get(t, "/trash")
+	assert.StringContains(t, body, "The trash is empty")
+
+	_, _, body = admin.get(t, "/trash")
+	assert.StringContains(t, body, "Hello")
+
+	code, _, _ = other.postForm(t, "/trash/restore/1", csrf)
+	assert.Equal(t, code, http.StatusNotFound)
+
+	code, _, _ = other.postForm(t, "/trash/delete/1", csrf)
+	assert.Equal(t, code, http.StatusNotFound)
+
+	_, _, body = author.get(t, "/trash")
+	assert.StringContains(t, body, "Hello")
+
+	code, _, _ = author.postForm(t, "/trash/restore/1", own)
+	assert.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestPostVisibility(t *testing.T) {
+	app := newTestApplication(t)
+	author := newTestServer(t, app.routes())
+	reader := newTestServer(t, app.routes())
+
+	author.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("title", "Protected post")
+	form.Add("content", "Partner preview")
+	form.Add("visibility", models.PostVisibilityProtected)
+	form.Add("csrf_token", author.csrfToken(t, "/post/add"))
+
+	code, _, body := author.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "This field cannot be empty")
+
+	form.Set("password", "open sesame")
+	code, header, _ := author.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/post/view/1")
+
+	form.Set("title", "Private post")
+	form.Set("content", "Internal draft")
+	form.Set("visibility", models.PostVisibilityPrivate)
+	author.postForm(t, "/post/add", form)
+
+	form.Set("title", "Unlisted post")
+	form.Set("content", "Shared by link")
+	form.Set("visibility", models.PostVisibilityUnlisted)
+	author.postForm(t, "/post/add", form)
+
+	_, _, body = reader.get(t, "/")
+	assert.StringContains(t, body, "No posts yet")
+
+	code, header, body = reader.get(t, "/post/view/3")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, header.Get("X-Robots-Tag"), "noindex")
+	assert.StringContains(t, body, "Shared by link")
+
+	code, _, _ = reader.get(t, "/post/view/2")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	_, _, body = author.get(t, "/post/view/2")
+	assert.StringContains(t, body, "Internal draft")
+
+	code, _, body = reader.get(t, "/post/view/1")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "Enter its passphrase to read it")
+	assert.Equal(t, strings.Contains(body, "Partner preview"), false)
+
+	unlock := url.Values{}
+	unlock.Add("password", "wrong")
+	unlock.Add("csrf_token", extractCSRFToken(t, body))
+
+	code, _, body = reader.postForm(t, "/post/unlock/1", unlock)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "The passphrase is incorrect")
+
+	unlock.Set("password", "open sesame")
+	code, header, _ = reader.postForm(t, "/post/unlock/1", unlock)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/post/view/1")
+
+	_, _, body = reader.get(t, "/post/view/1")
+	assert.StringContains(t, body, "Partner preview")
+
+	// A new passphrase locks the post again.
+	post, err := app.posts.Get(1)
+	assert.NilError(t, err)
+	_, err = app.posts.Save(post, nil, post.UserId, "new passphrase", "")
+	assert.NilError(t, err)
+
+	_, _, body = reader.get(t, "/post/view/1")
+	assert.StringContains(t, body, "Enter its passphrase to read it")
+
+	reader.loginAdmin(t, app, "carol")
+
+	_, _, body = reader.get(t, "/post/view/2")
+	assert.StringContains(t, body, "Internal draft")
+}
+
+func TestPostCards(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	ts.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("title", "Long read")
+	form.Add("content", "The first paragraph.\n\n"+strings.Repeat("word ", 400))
+	form.Add("coverImage", "cover.png")
+	form.Add("csrf_token", ts.csrfToken(t, "/post/add"))
+
+	code, _, body := ts.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "This field must be a full http or https URL")
+
+	form.Set("coverImage", "https://example.com/cover.png")
+	code, _, _ = ts.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, "/")
+	assert.StringContains(t, body, `<a href="/post/view/1">Long read</a>`)
+	assert.StringContains(t, body, `<img src="https://example.com/cover.png"`)
+	assert.StringContains(t, body, "3 min read")
+	assert.StringContains(t, body, "<p>The first paragraph.</p>")
+	assert.Equal(t, strings.Contains(body, "word word"), false)
+
+	form.Set("excerpt", "Settle in")
+	form.Set("csrf_token", ts.csrfToken(t, "/post/edit/1"))
+	code, _, _ = ts.postForm(t, "/post/edit/1", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, "/")
+	assert.StringContains(t, body, "<p>Settle in</p>")
+
+	_, _, body = ts.get(t, "/post/view/1")
+	assert.StringContains(t, body, `<meta property="og:description" content="Settle in">`)
+	assert.StringContains(t, body, `<meta property="og:image" content="https://example.com/cover.png">`)
+}
+
+func TestLocalization(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/", nil)
+	assert.NilError(t, err)
+	req.Header.Set("Accept-Language", "ja, en;q=0.5")
+
+	rs, err := ts.Client().Do(req)
+	assert.NilError(t, err)
+	_, header, body := readResponse(t, rs)
+	assert.Equal(t, header.Get("Content-Language"), "ja")
+	assert.StringContains(t, body, `<html lang="ja">`)
+	assert.StringContains(t, body, "まだ投稿はありません")
+
+	ts.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("language", "de")
+	form.Add("time-zone", "Mars/Olympus")
+	form.Add("csrf_token", ts.csrfToken(t, "/user/settings"))
+
+	code, _, body := ts.postForm(t, "/user/settings", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "This field must be a time zone such as Europe/Berlin")
+
+	form.Set("time-zone", "Europe/Berlin")
+	code, _, _ = ts.postForm(t, "/user/settings", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, header, body = ts.get(t, "/user/settings")
+	assert.Equal(t, header.Get("Content-Language"), "de")
+	assert.StringContains(t, body, "Einstellungen gespeichert")
+	assert.StringContains(t, body, `value="Europe/Berlin"`)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/i18n"
+)
+
+// localize picks the language and time zone for the request: the logged
+// in user's preferences if they have set them, otherwise the language
+// negotiated from the Accept-Language header and UTC. It must come after
+// authenticate.
+func (app *application) localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+		loc := time.UTC
+
+		if user := app.authenticatedUser(r); user != nil {
+			if i18n.Supported(user.Language) {
+				lang = user.Language
+			}
+			if user.TimeZone != "" {
+				if l, err := time.LoadLocation(user.TimeZone); err == nil {
+					loc = l
+				}
+			}
+		}
+
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", lang)
+
+		ctx := context.WithValue(r.Context(), localizerContextKey, i18n.New(lang, loc))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// localizer returns the request's localizer. Outside of the localize
+// middleware it is nil, which uses English and UTC.
+func (app *application) localizer(r *http.Request) *i18n.Localizer {
+	localizer, _ := r.Context().Value(localizerContextKey).(*i18n.Localizer)
+	return localizer
+}
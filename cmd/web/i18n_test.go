@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+// TestCatalogs checks that the catalogs translate the same messages and
+// that each of them is still used, either as a literal in the handlers or
+// templates or as the title of an error page.
+func TestCatalogs(t *testing.T) {
+	var sources strings.Builder
+
+	for _, pattern := range []string{"*.go", "../../ui/html/*/*.html", "../../ui/html/*.html"} {
+		files, err := filepath.Glob(pattern)
+		assert.NilError(t, err)
+
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			b, err := os.ReadFile(file)
+			assert.NilError(t, err)
+			sources.Write(b)
+		}
+	}
+
+	used := func(msg string) bool {
+		for status := range errorMessages {
+			if http.StatusText(status) == msg {
+				return true
+			}
+		}
+		return strings.Contains(sources.String(), strconv.Quote(msg))
+	}
+
+	var keys [][]string
+
+	for _, lang := range []string{"de", "ja"} {
+		b, err := os.ReadFile(filepath.Join("../../internal/i18n/locales", lang+".json"))
+		assert.NilError(t, err)
+
+		var catalog map[string]json.RawMessage
+		assert.NilError(t, json.Unmarshal(b, &catalog))
+
+		langKeys := []string{}
+		for key := range catalog {
+			if !used(key) {
+				t.Errorf("%s.json: %q isn't used", lang, key)
+			}
+			langKeys = append(langKeys, key)
+		}
+		slices.Sort(langKeys)
+
+		keys = append(keys, langKeys)
+	}
+
+	assert.Equal(t, slices.Equal(keys[0], keys[1]), true)
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/anxxuj/microblog/internal/importer"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// runImport implements the "import" subcommand, which loads posts from a
+// WordPress WXR export, a directory of Markdown files with YAML front
+// matter or the blog's own JSON export.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("web import", flag.ContinueOnError)
+	format := fs.String("format", "", "format of the input (wxr|markdown|json)")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without changing anything")
+	defaultAuthor := fs.String("default-author", "", "username to attribute posts to when their author has no matching user")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: web import -format wxr|markdown|json [flags] <file or directory>")
+		fs.PrintDefaults()
+	}
+
+	app, code := newCLIApplication(fs, args)
+	if app == nil {
+		return code
+	}
+	defer app.db.Close()
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	posts, skipped, err := readImport(*format, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	plan, err := app.planImport(posts, *defaultAuthor)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	plan.skippedInput = skipped
+	plan.report(os.Stdout)
+
+	if *dryRun {
+		return 0
+	}
+
+	if plan.unresolved > 0 {
+		fmt.Fprintln(os.Stderr, "import: some authors have no matching user; create them or pass -default-author")
+		return 1
+	}
+
+	n, err := app.applyImport(plan)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import: %s (%d posts imported before the error)\n", err, n)
+		return 1
+	}
+
+	fmt.Printf("imported %d posts\n", n)
+
+	return 0
+}
+
+func readImport(format, name string) ([]*importer.Post, int, error) {
+	switch format {
+	case "markdown":
+		posts, skipped, err := importer.ReadMarkdown(os.DirFS(name))
+		return posts, skipped, err
+	case "wxr", "json":
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer f.Close()
+
+		if format == "wxr" {
+			return importer.ReadWXR(f)
+		}
+
+		posts, err := importer.ReadJSON(f)
+		return posts, 0, err
+	default:
+		return nil, 0, fmt.Errorf("import: unknown format %q", format)
+	}
+}
+
+const (
+	importCreate     = "create"
+	importSkip       = "skip"
+	importUnresolved = "unresolved"
+)
+
+type importItem struct {
+	post   *importer.Post
+	userId int
+	action string
+	reason string
+}
+
+type importPlan struct {
+	items        []*importItem
+	skippedInput int
+	unresolved   int
+}
+
+// planImport matches the author of each post to a user, by username and
+// then by email, falling back to defaultAuthor, and decides whether the
+// post will be created or skipped because its slug is already taken.
+func (app *application) planImport(posts []*importer.Post, defaultAuthor string) (*importPlan, error) {
+	plan := &importPlan{}
+
+	var fallback *models.User
+	if defaultAuthor != "" {
+		user, err := app.users.GetByUsername(defaultAuthor)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				return nil, fmt.Errorf("import: default author %q does not exist", defaultAuthor)
+			}
+			return nil, err
+		}
+		fallback = user
+	}
+
+	authors := map[string]int{}
+	slugs := map[string]bool{}
+
+	for _, post := range posts {
+		item := &importItem{post: post, action: importCreate}
+		plan.items = append(plan.items, item)
+
+		key := post.Author + "\x00" + post.AuthorEmail
+		userId, ok := authors[key]
+		if !ok {
+			user, err := app.matchAuthor(post.Author, post.AuthorEmail)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case user != nil:
+				userId = user.Id
+			case fallback != nil:
+				userId = fallback.Id
+			}
+			authors[key] = userId
+		}
+		item.userId = userId
+
+		if post.Slug != "" {
+			if slugs[post.Slug] {
+				item.action, item.reason = importSkip, "duplicate slug in input"
+				continue
+			}
+			slugs[post.Slug] = true
+
+			_, err := app.posts.GetBySlug(post.Slug)
+			if err == nil {
+				item.action, item.reason = importSkip, "slug already exists"
+				continue
+			} else if !errors.Is(err, models.ErrNoRecord) {
+				return nil, err
+			}
+		}
+
+		if userId == 0 {
+			item.action, item.reason = importUnresolved, fmt.Sprintf("no user matches author %q", post.Author)
+			plan.unresolved++
+		}
+	}
+
+	return plan, nil
+}
+
+func (app *application) matchAuthor(username, email string) (*models.User, error) {
+	for _, lookup := range []struct {
+		value string
+		get   func(string) (*models.User, error)
+	}{
+		{username, app.users.GetByUsername},
+		{email, app.users.GetByEmail},
+	} {
+		if lookup.value == "" {
+			continue
+		}
+
+		user, err := lookup.get(lookup.value)
+		if err == nil {
+			return user, nil
+		} else if !errors.Is(err, models.ErrNoRecord) {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// applyImport creates the posts planned for creation and returns how many
+// were created.
+func (app *application) applyImport(plan *importPlan) (int, error) {
+	n := 0
+
+	for _, item := range plan.items {
+		if item.action != importCreate {
+			continue
+		}
+
+		_, err := app.posts.Import(&models.Post{
+			UserId:  item.userId,
+			Slug:    item.post.Slug,
+			Title:   item.post.Title,
+			Content: item.post.Content,
+			Tags:    item.post.Tags,
+			Created: item.post.Created,
+		})
+		if err != nil {
+			return n, fmt.Errorf("post %q: %w", item.post.Title, err)
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+func (plan *importPlan) report(w io.Writer) {
+	counts := map[string]int{}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tDATE\tAUTHOR\tSLUG\tTITLE\tNOTE")
+
+	for _, item := range plan.items {
+		counts[item.action]++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", item.action, item.post.Created.Format("2006-01-02"),
+			item.post.Author, item.post.Slug, item.post.Title, item.reason)
+	}
+
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d to create, %d to skip, %d with unresolved authors, %d input items ignored\n",
+		counts[importCreate], counts[importSkip], counts[importUnresolved], plan.skippedInput)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/importer"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestImport(t *testing.T) {
+	app := newTestApplication(t)
+
+	assert.NilError(t, app.users.Insert("alice", "alice@example.com", "pa$$word"))
+	assert.NilError(t, app.users.Insert("bobby", "bob@example.com", "pa$$word"))
+
+	created := time.Date(2015, 6, 1, 9, 30, 0, 0, time.UTC)
+
+	posts := []*importer.Post{
+		{Title: "By username", Author: "alice", Slug: "one", Created: created},
+		{Title: "By email", Author: "robert", AuthorEmail: "bob@example.com", Slug: "two", Created: created},
+		{Title: "Unknown author", Author: "mallory", Slug: "three", Created: created},
+		{Title: "Duplicate slug", Author: "alice", Slug: "one", Created: created},
+	}
+
+	plan, err := app.planImport(posts, "")
+	assert.NilError(t, err)
+	assert.Equal(t, plan.unresolved, 1)
+	assert.Equal(t, plan.items[0].userId, 1)
+	assert.Equal(t, plan.items[1].userId, 2)
+	assert.Equal(t, plan.items[2].action, importUnresolved)
+	assert.Equal(t, plan.items[3].action, importSkip)
+
+	var report bytes.Buffer
+	plan.report(&report)
+	assert.StringContains(t, report.String(), "2 to create, 1 to skip, 1 with unresolved authors")
+
+	_, err = app.planImport(posts, "nobody")
+	assert.Equal(t, err != nil, true)
+
+	plan, err = app.planImport(posts, "alice")
+	assert.NilError(t, err)
+	assert.Equal(t, plan.unresolved, 0)
+	assert.Equal(t, plan.items[2].userId, 1)
+
+	n, err := app.applyImport(plan)
+	assert.NilError(t, err)
+	assert.Equal(t, n, 3)
+
+	post, err := app.posts.GetBySlug("two")
+	assert.NilError(t, err)
+	assert.Equal(t, post.UserId, 2)
+	assert.Equal(t, post.Created, created)
+
+	plan, err = app.planImport(posts, "alice")
+	assert.NilError(t, err)
+	for _, item := range plan.items {
+		assert.Equal(t, item.action, importSkip)
+	}
+}
+
+func TestPostBySlug(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	id, err := app.posts.Import(&models.Post{Slug: "hello-world", Title: "Hello", Created: time.Now()})
+	assert.NilError(t, err)
+
+	code, header, _ := ts.get(t, "/p/hello-world")
+	assert.Equal(t, code, http.StatusMovedPermanently)
+	assert.Equal(t, header.Get("Location"), fmt.Sprintf("/post/view/%d", id))
+
+	code, _, _ = ts.get(t, "/p/missing")
+	assert.Equal(t, code, http.StatusNotFound)
+}
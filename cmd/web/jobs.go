@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// purgeTrash periodically removes posts that have outlived the trash
+// retention period until ctx is cancelled.
+func (app *application) purgeTrash(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := app.posts.Purge(app.config.TrashRetention)
+		if err != nil {
+			app.logger.Error("purging trash", "error", err)
+		} else if n > 0 {
+			app.logger.Info("purged trash", "posts", n)
+			app.recordAudit(&models.AuditEvent{Action: models.AuditPostPurge, TargetType: "post"}, nil, map[string]any{
+				"count":     n,
+				"retention": app.config.TrashRetention.String(),
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,70 +2,127 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"flag"
+	"fmt"
 	"html/template"
-	"log"
-	"net/http"
+	"io/fs"
+	"log/slog"
 	"os"
-	"time"
+	"sync"
+
+	// User time zones must resolve on hosts without a zoneinfo database.
+	_ "time/tzdata"
 
 	"github.com/alexedwards/scs/mysqlstore"
 	"github.com/alexedwards/scs/v2"
 	"github.com/anxxuj/microblog/internal/models"
+	"github.com/anxxuj/microblog/internal/spam"
+	"github.com/anxxuj/microblog/ui"
 	_ "github.com/go-sql-driver/mysql"
 )
 
 type application struct {
-	errorLog       *log.Logger
-	infoLog        *log.Logger
-	posts          *models.PostModel
+	assets         *assets
+	auditLog       models.AuditModelInterface
+	classifier     spam.Classifier
+	config         *config
+	db             *sql.DB
+	logger         *slog.Logger
+	metrics        *metrics
+	notifications  models.NotificationModelInterface
+	posts          models.PostModelInterface
+	series         models.SeriesModelInterface
 	sessionManager *scs.SessionManager
 	templateCache  map[string]*template.Template
-	users          *models.UserModel
+	ui             fs.FS
+	users          models.UserModelInterface
+	wg             sync.WaitGroup
 }
 
 func main() {
-	addr := flag.String("addr", ":4000", "http network address")
-	dsn := flag.String("dsn", "web:pass@/microblog?parseTime=true", "mysql data source name")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		case "backup":
+			os.Exit(runBackup(os.Args[2:]))
+		case "restore":
+			os.Exit(runRestore(os.Args[2:]))
+		}
+	}
 
-	flag.Parse()
+	cfg, printConfig, err := loadConfig(flag.NewFlagSet("web", flag.ContinueOnError), os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	errorLog := log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime|log.Llongfile)
-	infoLog := log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime)
+	if printConfig {
+		err = cfg.write(os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	db, err := openDB(*dsn)
+	logger, err := newLogger(os.Stdout, cfg.LogFormat, cfg.LogLevel)
 	if err != nil {
-		errorLog.Fatal(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	db, err := openDB(cfg.DSN)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 	defer db.Close()
 
-	templateCache, err := newTemplateCache()
+	uiFS, assets, templateCache, err := loadUI(cfg)
 	if err != nil {
-		errorLog.Fatal(err)
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 
+	metrics := newMetrics(db)
+
+	sessionStore := mysqlstore.New(db)
+	defer sessionStore.StopCleanup()
+
 	sessionManager := scs.New()
-	sessionManager.Store = mysqlstore.New(db)
-	sessionManager.Lifetime = 12 * time.Hour
+	sessionManager.Store = &instrumentedStore{Store: sessionStore, ops: metrics.sessionOps}
+	sessionManager.Lifetime = cfg.SessionLifetime
+	sessionManager.Cookie.Secure = cfg.tlsEnabled()
 
 	app := &application{
-		errorLog:       errorLog,
-		infoLog:        infoLog,
-		posts:          &models.PostModel{DB: db},
+		assets:         assets,
+		auditLog:       &models.AuditModel{DB: db},
+		classifier:     &spam.Heuristic{},
+		config:         cfg,
+		db:             db,
+		logger:         logger,
+		metrics:        metrics,
+		notifications:  &models.NotificationModel{DB: db},
+		posts:          &models.PostModel{DB: db, BcryptCost: cfg.BcryptCost},
+		series:         &models.SeriesModel{DB: db},
 		sessionManager: sessionManager,
 		templateCache:  templateCache,
-		users:          &models.UserModel{DB: db},
+		ui:             uiFS,
+		users:          &models.UserModel{DB: db, BcryptCost: cfg.BcryptCost},
 	}
 
-	srv := &http.Server{
-		Addr:     *addr,
-		ErrorLog: errorLog,
-		Handler:  app.routes(),
+	err = app.serve()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
-
-	infoLog.Printf("server starting on %s", *addr)
-	err = srv.ListenAndServe()
-	errorLog.Fatal(err)
 }
 
 func openDB(dsn string) (*sql.DB, error) {
@@ -78,3 +135,30 @@ func openDB(dsn string) (*sql.DB, error) {
 	}
 	return db, nil
 }
+
+// loadUI returns the file system holding the templates and static files,
+// either the embedded copy or, in development mode, the ui directory on
+// disk, along with the static assets and the parsed templates.
+func loadUI(cfg *config) (fs.FS, *assets, map[string]*template.Template, error) {
+	var uiFS fs.FS = ui.Files
+	if cfg.Dev {
+		uiFS = os.DirFS(cfg.UIDir)
+	}
+
+	staticFS, err := fs.Sub(uiFS, "static")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	assets, err := newAssets(staticFS, !cfg.Dev)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	templateCache, err := newTemplateCache(uiFS, assets.url)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return uiFS, assets, templateCache, nil
+}
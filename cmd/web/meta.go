@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// maxDescription is how many characters of a post's content are used as
+// its description when the post doesn't have one of its own.
+const maxDescription = 160
+
+// pageMeta describes a page to search engines and to the sites and chat
+// tools that show previews of shared links. The base layout turns it into
+// meta tags, using the page title where Title is empty and leaving out
+// other empty fields.
+type pageMeta struct {
+	Title       string
+	Description string
+	Canonical   string
+	// Type is the Open Graph type of the page, "website" or "article".
+	Type      string
+	Image     string
+	Author    string
+	Published time.Time
+	Modified  time.Time
+	// Schema is the schema.org description of the page, rendered as
+	// JSON-LD.
+	Schema any
+}
+
+// newPageMeta returns the metadata of an ordinary page.
+func (app *application) newPageMeta(r *http.Request) *pageMeta {
+	return &pageMeta{Type: "website", Canonical: app.baseURL(r) + r.URL.Path}
+}
+
+// postMeta returns the metadata of a post's page, applying the overrides
+// set on the post form and falling back to the post's excerpt and cover
+// image.
+func (app *application) postMeta(r *http.Request, post *models.Post) *pageMeta {
+	meta := &pageMeta{
+		Title:       post.Title,
+		Description: models.Excerpt(post.Content, maxDescription),
+		Canonical:   fmt.Sprintf("%s/post/view/%d", app.baseURL(r), post.Id),
+		Type:        "article",
+		Image:       post.Meta.Image,
+		Author:      post.Author,
+		Published:   post.Created,
+		Modified:    post.Updated,
+	}
+
+	if post.Meta.Title != "" {
+		meta.Title = post.Meta.Title
+	}
+	if post.Meta.Description != "" {
+		meta.Description = post.Meta.Description
+	} else if post.Excerpt != "" {
+		meta.Description = post.Excerpt
+	}
+	if meta.Image == "" {
+		meta.Image = post.CoverImage
+	}
+
+	posting := &blogPosting{
+		Context:          "https://schema.org",
+		Type:             "BlogPosting",
+		Headline:         meta.Title,
+		Description:      meta.Description,
+		URL:              meta.Canonical,
+		MainEntityOfPage: meta.Canonical,
+		Image:            meta.Image,
+		DatePublished:    meta.Published.UTC().Truncate(time.Second),
+		DateModified:     meta.Modified.UTC().Truncate(time.Second),
+	}
+	if meta.Author != "" {
+		posting.Author = &schemaPerson{Type: "Person", Name: meta.Author}
+	}
+	meta.Schema = posting
+
+	return meta
+}
+
+// blogPosting is a schema.org BlogPosting.
+type blogPosting struct {
+	Context          string        `json:"@context"`
+	Type             string        `json:"@type"`
+	Headline         string        `json:"headline"`
+	Description      string        `json:"description,omitempty"`
+	URL              string        `json:"url"`
+	MainEntityOfPage string        `json:"mainEntityOfPage"`
+	Image            string        `json:"image,omitempty"`
+	DatePublished    time.Time     `json:"datePublished"`
+	DateModified     time.Time     `json:"dateModified"`
+	Author           *schemaPerson `json:"author,omitempty"`
+}
+
+type schemaPerson struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
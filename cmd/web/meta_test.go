@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestPostMeta(t *testing.T) {
+	app := newTestApplication(t)
+	app.config.BaseURL = "https://blog.example.com"
+	author := newTestServer(t, app.routes())
+	reader := newTestServer(t, app.routes())
+
+	author.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("title", "Shipping <fast>")
+	form.Add("content", "Small  changes\nland sooner. "+strings.Repeat("word ", 50))
+	form.Add("csrf_token", author.csrfToken(t, "/post/add"))
+
+	code, _, _ := author.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body := reader.get(t, "/post/view/1")
+	assert.StringContains(t, body, `<link rel="canonical" href="https://blog.example.com/post/view/1">`)
+	assert.StringContains(t, body, `<meta property="og:type" content="article">`)
+	assert.StringContains(t, body, `<meta property="og:title" content="Shipping &lt;fast&gt;">`)
+	assert.StringContains(t, body, `<meta name="description" content="Small changes land sooner. word`)
+	assert.StringContains(t, body, `word…">`)
+	assert.StringContains(t, body, `<meta name="twitter:card" content="summary">`)
+	assert.StringContains(t, body, `<script type="application/ld+json">{"@context":"https://schema.org","@type":"BlogPosting","headline":"Shipping \u003cfast\u003e"`)
+
+	form.Set("title", "Ship it")
+	form.Set("metaImage", "/cover.png")
+	form.Set("csrf_token", author.csrfToken(t, "/post/edit/1"))
+
+	code, _, body = author.postForm(t, "/post/edit/1", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "This field must be a full http or https URL")
+
+	form.Set("metaTitle", "Why small changes win")
+	form.Set("metaDescription", "Notes on shipping")
+	form.Set("metaImage", "https://cdn.example.com/cover.png")
+
+	code, _, _ = author.postForm(t, "/post/edit/1", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = reader.get(t, "/post/view/1")
+	assert.StringContains(t, body, `<meta property="og:title" content="Why small changes win">`)
+	assert.StringContains(t, body, `<meta property="og:description" content="Notes on shipping">`)
+	assert.StringContains(t, body, `<meta property="og:image" content="https://cdn.example.com/cover.png">`)
+	assert.StringContains(t, body, `<meta name="twitter:card" content="summary_large_image">`)
+	assert.StringContains(t, body, "<h1>Ship it</h1>")
+
+	// Editing without the fields, e.g. from an older form, keeps them.
+	form.Del("metaTitle")
+	form.Del("metaDescription")
+	form.Del("metaImage")
+	author.postForm(t, "/post/edit/1", form)
+
+	post, err := app.posts.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Meta.Title, "Why small changes win")
+
+	_, _, body = reader.get(t, "/archive")
+	assert.StringContains(t, body, `<meta property="og:type" content="website">`)
+	assert.StringContains(t, body, `<link rel="canonical" href="https://blog.example.com/archive">`)
+	assert.Equal(t, strings.Contains(body, "application/ld+json"), false)
+}
+
+func TestPostMetaProtected(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	locked := &models.Post{Title: "Locked", Content: "The secret is out", Status: models.PostStatusPublished,
+		Visibility: models.PostVisibilityProtected, Meta: models.PostMeta{Description: "Hidden summary"}}
+	_, err := app.posts.Save(locked, nil, 0, "open sesame", "")
+	assert.NilError(t, err)
+
+	_, _, body := ts.get(t, "/post/view/1")
+	assert.Equal(t, strings.Contains(body, "The secret is out"), false)
+	assert.Equal(t, strings.Contains(body, "Hidden summary"), false)
+	assert.Equal(t, strings.Contains(body, "application/ld+json"), false)
+}
+
+func TestPostMetaDates(t *testing.T) {
+	app := newTestApplication(t)
+
+	created := time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC)
+	updated := created.Add(48 * time.Hour)
+
+	r := httptest.NewRequest(http.MethodGet, "/post/view/1", nil)
+	meta := app.postMeta(r, &models.Post{Id: 1, Title: "Dated", Created: created, Updated: updated})
+
+	assert.Equal(t, meta.Published, created)
+	assert.Equal(t, meta.Modified, updated)
+}
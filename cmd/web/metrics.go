@@ -0,0 +1,126 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	sessionOps      *prometheus.CounterVec
+	renderDuration  *prometheus.HistogramVec
+}
+
+func newMetrics(db *sql.DB) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "microblog_http_requests_total",
+			Help: "Number of HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "microblog_http_request_duration_seconds",
+			Help:    "Duration of HTTP requests by route and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		sessionOps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "microblog_session_store_operations_total",
+			Help: "Number of session store operations by operation and result.",
+		}, []string{"operation", "result"}),
+		renderDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "microblog_template_render_duration_seconds",
+			Help:    "Duration of template rendering by page.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+		}, []string{"page"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.sessionOps,
+		m.renderDuration,
+	)
+
+	if db != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, "microblog"))
+	}
+
+	return m
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// observeRequest records the count and duration of a completed request. An
+// empty route means the request didn't match any route.
+func (m *metrics) observeRequest(route, method string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+
+	m.requests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// labeledRouter records the pattern of the matched route on each request,
+// so that metrics are grouped by route rather than by raw URL.
+type labeledRouter struct {
+	*httprouter.Router
+}
+
+func (lr labeledRouter) Handler(method, path string, handler http.Handler) {
+	lr.Router.Handler(method, path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestInfoFromRequest(r).route = path
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+func (lr labeledRouter) HandlerFunc(method, path string, handler http.HandlerFunc) {
+	lr.Handler(method, path, handler)
+}
+
+// instrumentedStore counts the operations of the wrapped session store.
+type instrumentedStore struct {
+	scs.Store
+	ops *prometheus.CounterVec
+}
+
+func (s *instrumentedStore) observe(op string, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	s.ops.WithLabelValues(op, result).Inc()
+}
+
+func (s *instrumentedStore) Find(token string) ([]byte, bool, error) {
+	b, found, err := s.Store.Find(token)
+	s.observe("find", err)
+	return b, found, err
+}
+
+func (s *instrumentedStore) Commit(token string, b []byte, expiry time.Time) error {
+	err := s.Store.Commit(token, b, expiry)
+	s.observe("commit", err)
+	return err
+}
+
+func (s *instrumentedStore) Delete(token string) error {
+	err := s.Store.Delete(token)
+	s.observe("delete", err)
+	return err
+}
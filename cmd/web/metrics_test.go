@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestMetricsListener(t *testing.T) {
+	app := newTestApplication(t)
+
+	public := newTestServer(t, app.routes())
+	code, _, _ := public.get(t, "/metrics")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	public.get(t, "/healthz")
+
+	private := newTestServer(t, app.metricsRoutes())
+	code, _, body := private.get(t, "/metrics")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "microblog_http_requests_total")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	app := newTestApplication(t)
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	app.secureHeaders(next).ServeHTTP(rr, r)
+
+	rs := rr.Result()
+
+	assert.Equal(t, rs.Header.Get("Content-Security-Policy"), app.config.CSP)
+	assert.Equal(t, rs.Header.Get("Referrer-Policy"), "origin-when-cross-origin")
+	assert.Equal(t, rs.Header.Get("X-Content-Type-Options"), "nosniff")
+	assert.Equal(t, rs.Header.Get("X-Frame-Options"), "deny")
+	assert.Equal(t, rs.Header.Get("X-XSS-Protection"), "0")
+	assert.Equal(t, rs.StatusCode, http.StatusOK)
+	assert.Equal(t, rr.Body.String(), "OK")
+}
+
+func TestRequestID(t *testing.T) {
+	app := newTestApplication(t)
+
+	var seen string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestInfoFromRequest(r).id
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		wantSame bool
+	}{
+		{"No header", "", false},
+		{"Valid header", "abc-123", true},
+		{"Invalid header", "abc 123<script>", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("X-Request-ID", tt.header)
+			}
+
+			app.requestID(next).ServeHTTP(rr, r)
+
+			id := rr.Header().Get("X-Request-ID")
+			assert.Equal(t, id, seen)
+			assert.Equal(t, id == tt.header, tt.wantSame)
+			assert.Equal(t, id != "", true)
+		})
+	}
+}
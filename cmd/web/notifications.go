@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// notificationsPageSize is how many of the most recent notifications the
+// notifications page shows.
+const notificationsPageSize = 50
+
+// notificationLabels describe each kind of notification on the settings
+// page, to be translated.
+var notificationLabels = map[string]string{
+	models.NotificationFollow:   "New followers",
+	models.NotificationReaction: "Reactions to my posts",
+	models.NotificationReview:   "Review decisions on my posts",
+	models.NotificationMention:  "Mentions of me",
+}
+
+func notificationLabel(kind string) string {
+	return notificationLabels[kind]
+}
+
+// notify records n, with the logged in user as its actor unless the caller
+// already set one. Users aren't notified of their own actions, nor of
+// actions on posts without an author. Failing to record a notification is
+// logged but doesn't fail the request.
+func (app *application) notify(r *http.Request, n *models.Notification) {
+	if n.ActorId == 0 {
+		n.ActorId = app.authenticatedUserID(r)
+	}
+	if n.UserId == 0 || n.UserId == n.ActorId {
+		return
+	}
+
+	err := app.notifications.Insert(n)
+	if err != nil {
+		app.logger.Error("recording notification", "kind", n.Kind, "error", err)
+	}
+}
+
+// unreadNotifications returns how many unread notifications the logged in
+// user has for the counter in the navigation, or 0 if the request isn't
+// authenticated. Errors are logged rather than failing the page.
+func (app *application) unreadNotifications(r *http.Request) int {
+	id := app.authenticatedUserID(r)
+	if id == 0 {
+		return 0
+	}
+
+	n, err := app.notifications.Unread(id)
+	if err != nil {
+		app.logger.Error("counting unread notifications", "error", err)
+	}
+
+	return n
+}
+
+func (app *application) notificationList(w http.ResponseWriter, r *http.Request) {
+	notifications, err := app.notifications.List(app.authenticatedUserID(r), notificationsPageSize)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Notifications = notifications
+	app.renderTemplate(w, r, http.StatusOK, "notifications.html", data)
+}
+
+// notificationsReadPost marks the notification named by the id form field
+// as read, or all of the user's notifications if there is none.
+func (app *application) notificationsReadPost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	id := 0
+
+	if v := r.PostForm.Get("id"); v != "" {
+		id, err = strconv.Atoi(v)
+		if err != nil || id < 1 {
+			app.clientError(w, r, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = app.notifications.MarkRead(app.authenticatedUserID(r), id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/notifications", http.StatusSeeOther)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestNotifications(t *testing.T) {
+	app := newTestApplication(t)
+	alice := newTestServer(t, app.routes())
+	bob := newTestServer(t, app.routes())
+
+	alice.login(t, app, "alice", "pa$$word")
+	bob.login(t, app, "bobby", "pa$$word")
+
+	aliceUser, err := app.users.GetByUsername("alice")
+	assert.NilError(t, err)
+
+	newPost(t, app, aliceUser.Id, "Frogs", "A frog jumps", models.PostStatusPublished, models.PostVisibilityPublic)
+
+	_, _, body := alice.get(t, "/notifications")
+	assert.StringContains(t, body, "Nothing new")
+	assert.Equal(t, strings.Contains(body, "unread-count"), false)
+
+	form := url.Values{}
+	form.Add("csrf_token", bob.csrfToken(t, "/u/alice"))
+
+	// Following twice and reacting to one's own post notify only once.
+	bob.postForm(t, "/u/alice/follow", form)
+	bob.postForm(t, "/u/alice/follow", form)
+
+	form.Set("kind", models.ReactionLove)
+	bob.postForm(t, "/post/react/1", form)
+
+	own := url.Values{}
+	own.Add("kind", models.ReactionLike)
+	own.Add("csrf_token", alice.csrfToken(t, "/post/view/1"))
+	alice.postForm(t, "/post/react/1", own)
+
+	_, _, body = alice.get(t, "/notifications")
+	assert.StringContains(t, body, `<span class="unread-count">2</span>`)
+	assert.StringContains(t, body, "bobby started following you")
+	assert.StringContains(t, body, "bobby reacted ❤️ to “Frogs”")
+
+	notifications, err := app.notifications.List(aliceUser.Id, notificationsPageSize)
+	assert.NilError(t, err)
+	assert.Equal(t, len(notifications), 2)
+
+	read := url.Values{}
+	read.Add("id", "1")
+	read.Add("csrf_token", extractCSRFToken(t, body))
+
+	code, header, _ := alice.postForm(t, "/notifications/read", read)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/notifications")
+
+	_, _, body = alice.get(t, "/")
+	assert.StringContains(t, body, `<span class="unread-count">1</span>`)
+
+	// Bob can't mark Alice's notifications as read.
+	read.Set("id", "2")
+	read.Set("csrf_token", bob.csrfToken(t, "/user/settings"))
+	bob.postForm(t, "/notifications/read", read)
+
+	unread, err := app.notifications.Unread(aliceUser.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, unread, 1)
+
+	read.Del("id")
+	read.Set("csrf_token", alice.csrfToken(t, "/notifications"))
+	alice.postForm(t, "/notifications/read", read)
+
+	_, _, body = alice.get(t, "/")
+	assert.Equal(t, strings.Contains(body, "unread-count"), false)
+
+	read.Set("id", "two")
+	code, _, _ = alice.postForm(t, "/notifications/read", read)
+	assert.Equal(t, code, http.StatusBadRequest)
+
+	// Turning reactions off keeps the other kinds on.
+	settings := url.Values{}
+	settings.Add("notify", models.NotificationFollow)
+	settings.Add("notify", models.NotificationReview)
+	settings.Add("csrf_token", alice.csrfToken(t, "/user/settings"))
+
+	code, _, _ = alice.postForm(t, "/user/settings", settings)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = alice.get(t, "/user/settings")
+	assert.StringContains(t, body, `value="follow" checked`)
+	assert.StringContains(t, body, `value="reaction">`)
+
+	form.Set("kind", models.ReactionWow)
+	bob.postForm(t, "/post/react/1", form)
+
+	unread, err = app.notifications.Unread(aliceUser.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, unread, 0)
+
+	code, _, _ = newTestServer(t, app.routes()).get(t, "/notifications")
+	assert.Equal(t, code, http.StatusSeeOther)
+
+}
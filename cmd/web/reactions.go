@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+// reactionEmoji is how each kind of reaction is shown, and reactionLabels
+// its name for screen readers and tooltips, to be translated.
+var (
+	reactionEmoji = map[string]string{
+		models.ReactionLike:  "👍",
+		models.ReactionLove:  "❤️",
+		models.ReactionLaugh: "😄",
+		models.ReactionWow:   "😮",
+		models.ReactionSad:   "😢",
+	}
+	reactionLabels = map[string]string{
+		models.ReactionLike:  "Like",
+		models.ReactionLove:  "Love",
+		models.ReactionLaugh: "Funny",
+		models.ReactionWow:   "Surprising",
+		models.ReactionSad:   "Sad",
+	}
+)
+
+func emoji(kind string) string {
+	return reactionEmoji[kind]
+}
+
+func reactionLabel(kind string) string {
+	return reactionLabels[kind]
+}
+
+// postReactPost adds or takes back the user's reaction to a post. It is a
+// plain form post so reacting works without JavaScript.
+func (app *application) postReactPost(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	post, err := app.posts.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if !app.canView(r, post) {
+		app.notFound(w, r)
+		return
+	}
+
+	if app.isLocked(r, post) {
+		app.clientError(w, r, http.StatusForbidden)
+		return
+	}
+
+	kind := r.PostForm.Get("kind")
+
+	added, err := app.posts.React(id, app.authenticatedUserID(r), kind)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidReaction) {
+			app.clientError(w, r, http.StatusBadRequest)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if added {
+		app.notify(r, &models.Notification{UserId: post.UserId, Kind: models.NotificationReaction, PostId: id, Detail: kind})
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/view/%d#reactions", id), http.StatusSeeOther)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestReactions(t *testing.T) {
+	app := newTestApplication(t)
+	alice := newTestServer(t, app.routes())
+	dave := newTestServer(t, app.routes())
+	reader := newTestServer(t, app.routes())
+
+	quiet := newPost(t, app, 0, "Quiet post", "Nobody reacts", models.PostStatusPublished, models.PostVisibilityPublic)
+	loved := newPost(t, app, 0, "Loved post", "Everybody reacts", models.PostStatusPublished, models.PostVisibilityPublic)
+	newPost(t, app, 0, "Newest post", "Just in", models.PostStatusPublished, models.PostVisibilityPublic)
+
+	alice.login(t, app, "alice", "pa$$word")
+	dave.login(t, app, "dave", "pa$$word")
+
+	_, _, body := reader.get(t, "/post/view/2")
+	assert.StringContains(t, body, `<a href="/user/login">Log in to react</a>`)
+	assert.Equal(t, strings.Contains(body, `action="/post/react/2"`), false)
+
+	form := url.Values{}
+	form.Add("kind", models.ReactionLove)
+	form.Add("csrf_token", reader.csrfToken(t, "/user/login"))
+
+	code, header, _ := reader.postForm(t, "/post/react/2", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/user/login")
+
+	form.Set("csrf_token", alice.csrfToken(t, "/post/view/2"))
+	code, header, _ = alice.postForm(t, "/post/react/2", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/post/view/2#reactions")
+
+	form.Set("kind", models.ReactionLike)
+	alice.postForm(t, "/post/react/2", form)
+
+	form.Set("kind", models.ReactionLove)
+	form.Set("csrf_token", dave.csrfToken(t, "/post/view/2"))
+	dave.postForm(t, "/post/react/2", form)
+
+	_, _, body = alice.get(t, "/post/view/2")
+	assert.StringContains(t, body, `<button name="kind" value="love" class="reacted" aria-pressed="true" title="Love">❤️ 2</button>`)
+	assert.StringContains(t, body, `<button name="kind" value="like" class="reacted" aria-pressed="true" title="Like">👍 1</button>`)
+	assert.StringContains(t, body, `<button name="kind" value="sad" aria-pressed="false" title="Sad">😢 0</button>`)
+
+	// Reacting the same way again takes the reaction back.
+	form.Set("kind", models.ReactionLike)
+	form.Set("csrf_token", alice.csrfToken(t, "/post/view/2"))
+	alice.postForm(t, "/post/react/2", form)
+
+	_, _, body = reader.get(t, "/post/view/2")
+	assert.StringContains(t, body, `<span title="Love">❤️ 2</span>`)
+	assert.Equal(t, strings.Contains(body, `title="Like"`), false)
+
+	_, _, body = reader.get(t, "/?sort=reactions")
+	assert.StringContains(t, body, "2 reactions")
+	assert.Equal(t, strings.Index(body, "Loved post") < strings.Index(body, "Newest post"), true)
+	assert.Equal(t, strings.Index(body, "Newest post") < strings.Index(body, "Quiet post"), true)
+
+	_, _, body = reader.get(t, "/")
+	assert.Equal(t, strings.Index(body, "Newest post") < strings.Index(body, "Loved post"), true)
+
+	code, _, _ = reader.get(t, "/?sort=random")
+	assert.Equal(t, code, http.StatusBadRequest)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		kind     string
+		wantCode int
+	}{
+		{"Unknown kind", "/post/react/2", "angry", http.StatusBadRequest},
+		{"Missing post", "/post/react/99", models.ReactionLike, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form.Set("kind", tt.kind)
+			code, _, _ := alice.postForm(t, tt.urlPath, form)
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+
+	counts, err := app.posts.Reactions(quiet, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(counts), len(models.ReactionKinds()))
+
+	post, err := app.posts.Get(loved)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Reactions, 2)
+}
+
+func TestReactionsLockedPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	locked := &models.Post{Title: "Locked", Content: "Secret", Status: models.PostStatusPublished,
+		Visibility: models.PostVisibilityProtected}
+	_, err := app.posts.Save(locked, nil, 0, "open sesame", "")
+	assert.NilError(t, err)
+
+	ts.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("kind", models.ReactionLike)
+	form.Add("csrf_token", ts.csrfToken(t, "/post/view/1"))
+
+	code, _, _ := ts.postForm(t, "/post/react/1", form)
+	assert.Equal(t, code, http.StatusForbidden)
+}
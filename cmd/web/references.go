@@ -0,0 +1,85 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+// notifyMentions notifies the users a post mentions who haven't been
+// notified yet. It is called whenever a post is saved or published, since
+// mentions in posts that others can't read wait until they can. Errors are
+// logged rather than failing the request.
+func (app *application) notifyMentions(r *http.Request, postId int) {
+	notifications, err := app.posts.ClaimMentions(postId)
+	if err != nil {
+		app.logger.Error("claiming mentions", "post", postId, "error", err)
+		return
+	}
+
+	for _, n := range notifications {
+		app.notify(r, n)
+	}
+}
+
+// linkify returns content as HTML with the mentions of the given users
+// linked to their profiles and hashtags linked to their listings. Other
+// mentions are left as text.
+func linkify(content string, mentions []string) template.HTML {
+	var b strings.Builder
+
+	last := 0
+	for _, ref := range models.FindReferences(content) {
+		var href string
+		if !ref.Mention {
+			href = "/hashtag/" + url.PathEscape(ref.Name)
+		} else if slices.ContainsFunc(mentions, func(name string) bool { return strings.EqualFold(name, ref.Name) }) {
+			href = "/u/" + ref.Name
+		} else {
+			continue
+		}
+
+		b.WriteString(template.HTMLEscapeString(content[last:ref.Start]))
+		b.WriteString(`<a href="` + template.HTMLEscapeString(href) + `">`)
+		b.WriteString(template.HTMLEscapeString(content[ref.Start:ref.End]))
+		b.WriteString("</a>")
+		last = ref.End
+	}
+	b.WriteString(template.HTMLEscapeString(content[last:]))
+
+	return template.HTML(b.String())
+}
+
+// hashtagView lists the public posts with the hashtag in the URL.
+func (app *application) hashtagView(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	tag := params.ByName("tag")
+
+	refs := models.FindReferences("#" + tag)
+	if len(refs) != 1 || refs[0].End != len(tag)+1 {
+		app.notFound(w, r)
+		return
+	}
+
+	if refs[0].Name != tag {
+		http.Redirect(w, r, "/hashtag/"+url.PathEscape(refs[0].Name), http.StatusMovedPermanently)
+		return
+	}
+
+	posts, err := app.posts.LatestByHashtag(tag)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Hashtag = tag
+	data.Posts = posts
+	app.renderTemplate(w, r, http.StatusOK, "hashtag.html", data)
+}
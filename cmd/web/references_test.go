@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestLinkify(t *testing.T) {
+	got := linkify("<b>Hi</b> @alice, @Carol and @nobody #Go a@b.com", []string{"alice", "carol"})
+
+	assert.Equal(t, string(got), `&lt;b&gt;Hi&lt;/b&gt; <a href="/u/alice">@alice</a>, <a href="/u/Carol">@Carol</a> and @nobody <a href="/hashtag/go">#Go</a> a@b.com`)
+}
+
+func TestMentionsAndHashtags(t *testing.T) {
+	app := newTestApplication(t)
+	alice := newTestServer(t, app.routes())
+	bob := newTestServer(t, app.routes())
+	reader := newTestServer(t, app.routes())
+
+	alice.login(t, app, "alice", "pa$$word")
+	bob.login(t, app, "bobby", "pa$$word")
+
+	form := url.Values{}
+	form.Add("title", "Frogs")
+	form.Add("content", "Thanks @bobby and @nobody for the #Haiku tips")
+	form.Add("csrf_token", alice.csrfToken(t, "/post/add"))
+
+	code, _, _ := alice.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body := reader.get(t, "/post/view/1")
+	assert.StringContains(t, body, `Thanks <a href="/u/bobby">@bobby</a> and @nobody for the <a href="/hashtag/haiku">#Haiku</a> tips`)
+
+	_, _, body = bob.get(t, "/notifications")
+	assert.StringContains(t, body, "alice mentioned you in “Frogs”")
+
+	// Saving the post again doesn't mention Bob again.
+	form.Set("csrf_token", alice.csrfToken(t, "/post/edit/1"))
+	code, _, _ = alice.postForm(t, "/post/edit/1", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	bobby, err := app.users.GetByUsername("bobby")
+	assert.NilError(t, err)
+
+	unread, err := app.notifications.Unread(bobby.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, unread, 1)
+
+	_, _, body = reader.get(t, "/hashtag/haiku")
+	assert.StringContains(t, body, "<h1>#haiku</h1>")
+	assert.StringContains(t, body, `<a href="/post/view/1">Frogs</a>`)
+
+	code, header, _ := reader.get(t, "/hashtag/Haiku")
+	assert.Equal(t, code, http.StatusMovedPermanently)
+	assert.Equal(t, header.Get("Location"), "/hashtag/haiku")
+
+	code, _, _ = reader.get(t, "/hashtag/123")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	_, _, body = reader.get(t, "/hashtag/frogs")
+	assert.StringContains(t, body, "No posts with this hashtag yet")
+
+	// Private posts are listed nowhere and mention nobody.
+	form.Set("title", "Secret")
+	form.Set("content", "Only for me, @bobby #secret")
+	form.Set("visibility", models.PostVisibilityPrivate)
+	form.Set("csrf_token", alice.csrfToken(t, "/post/add"))
+
+	code, _, _ = alice.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	unread, err = app.notifications.Unread(bobby.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, unread, 1)
+
+	_, _, body = reader.get(t, "/hashtag/secret")
+	assert.Equal(t, strings.Contains(body, "Secret"), false)
+}
+
+func TestMentionsNotifiedWhenPublished(t *testing.T) {
+	app := newTestApplication(t)
+	app.config.RequireReview = true
+
+	alice := newTestServer(t, app.routes())
+	editor := newTestServer(t, app.routes())
+
+	alice.login(t, app, "alice", "pa$$word")
+	editor.login(t, app, "carol", "pa$$word")
+
+	carol, err := app.users.GetByUsername("carol")
+	assert.NilError(t, err)
+	assert.NilError(t, app.users.SetRole(carol.Id, models.RoleEditor))
+
+	form := url.Values{}
+	form.Add("title", "Thanks")
+	form.Add("content", "Thanks @carol for reviewing")
+	form.Add("csrf_token", alice.csrfToken(t, "/post/add"))
+
+	code, _, _ := alice.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	unread, err := app.notifications.Unread(carol.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, unread, 0)
+
+	review := url.Values{}
+	review.Add("action", models.ReviewApprove)
+	review.Add("csrf_token", editor.csrfToken(t, "/review/1"))
+
+	code, _, _ = editor.postForm(t, "/review/1", review)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body := editor.get(t, "/notifications")
+	assert.StringContains(t, body, "alice mentioned you in “Thanks”")
+}
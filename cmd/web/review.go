@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+// needsReview reports whether posts written by the user making the request
+// must be approved by a reviewer before they are published.
+func (app *application) needsReview(r *http.Request) bool {
+	return app.authorNeedsReview(app.authenticatedUser(r))
+}
+
+// authorNeedsReview reports whether posts written by user must be approved
+// by a reviewer before they are published. Posts without an author, whose
+// user is nil, always need review when it is required.
+func (app *application) authorNeedsReview(user *models.User) bool {
+	return app.config.RequireReview && !user.Can(models.PermissionReview)
+}
+
+// reviewAudit is what the audit log keeps of a step in the editorial
+// workflow.
+type reviewAudit struct {
+	Action string `json:"action"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Note   string `json:"note,omitempty"`
+}
+
+// transition takes action on a post on behalf of the logged in user and
+// records it in the audit log.
+func (app *application) transition(r *http.Request, postId int, action, note string) error {
+	review, err := app.posts.Transition(postId, app.authenticatedUserID(r), action, note)
+	if err != nil {
+		return err
+	}
+
+	app.auditReview(r, review)
+
+	if review.To == models.PostStatusPublished {
+		app.notifyMentions(r, postId)
+	}
+
+	return nil
+}
+
+// auditReview records a step in the editorial workflow in the audit log.
+func (app *application) auditReview(r *http.Request, review *models.Review) {
+	app.audit(r, &models.AuditEvent{Action: models.AuditPostReview, TargetType: "post", TargetId: review.PostId},
+		nil, &reviewAudit{Action: review.Action, From: review.From, To: review.To, Note: review.Note})
+}
+
+func (app *application) postSubmitPost(w http.ResponseWriter, r *http.Request) {
+	app.authorTransition(w, r, models.ReviewSubmit, "Your post has been submitted for review")
+}
+
+func (app *application) postWithdrawPost(w http.ResponseWriter, r *http.Request) {
+	app.authorTransition(w, r, models.ReviewWithdraw, "Your post has been withdrawn from review")
+}
+
+// authorTransition lets the author of the post named by the :id parameter
+// take action on it.
+func (app *application) authorTransition(w http.ResponseWriter, r *http.Request, action, flash string) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	post, err := app.posts.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if post.UserId != app.authenticatedUserID(r) {
+		app.clientError(w, r, http.StatusForbidden)
+		return
+	}
+
+	err = app.transition(r, id, action, "")
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidTransition) {
+			app.clientError(w, r, http.StatusConflict)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", flash)
+
+	http.Redirect(w, r, fmt.Sprintf("/post/view/%d", id), http.StatusSeeOther)
+}
+
+// canReview reports whether the user making the request may read a post
+// waiting for review. Reviewers only see private posts if they may view
+// private posts, and protected posts once they have unlocked them.
+func (app *application) canReview(r *http.Request, post *models.Post) bool {
+	return app.canView(r, post) && !app.isLocked(r, post)
+}
+
+// reviewQueue lists the posts waiting for review that the reviewer may
+// read, longest waiting first.
+func (app *application) reviewQueue(w http.ResponseWriter, r *http.Request) {
+	posts, err := app.posts.List(models.PostStatusInReview)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	posts = slices.DeleteFunc(posts, func(post *models.Post) bool { return !app.canReview(r, post) })
+	slices.Reverse(posts)
+
+	data := app.newTemplateData(r)
+	data.Posts = posts
+	app.renderTemplate(w, r, http.StatusOK, "review_queue.html", data)
+}
+
+func (app *application) review(w http.ResponseWriter, r *http.Request) {
+	post, ok := app.postFromParams(w, r, models.PostStatusInReview)
+	if !ok {
+		return
+	}
+
+	if !app.canReview(r, post) {
+		app.notFound(w, r)
+		return
+	}
+
+	app.renderReview(w, r, http.StatusOK, post, &reviewForm{})
+}
+
+func (app *application) reviewPost(w http.ResponseWriter, r *http.Request) {
+	post, ok := app.postFromParams(w, r, models.PostStatusInReview)
+	if !ok {
+		return
+	}
+
+	if !app.canReview(r, post) {
+		app.notFound(w, r)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, r, http.StatusBadRequest)
+		return
+	}
+
+	form := &reviewForm{
+		Action: r.PostForm.Get("action"),
+		Note:   r.PostForm.Get("note"),
+	}
+
+	if !form.Validate() {
+		app.renderReview(w, r, http.StatusUnprocessableEntity, post, form)
+		return
+	}
+
+	err = app.transition(r, post.Id, form.Action, form.Note)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidTransition) {
+			app.clientError(w, r, http.StatusConflict)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.notify(r, &models.Notification{UserId: post.UserId, Kind: models.NotificationReview, PostId: post.Id, Detail: form.Action})
+
+	if form.Action == models.ReviewApprove {
+		app.sessionManager.Put(r.Context(), "flash", "Post approved and published")
+	} else {
+		app.sessionManager.Put(r.Context(), "flash", "Changes requested")
+	}
+
+	http.Redirect(w, r, "/review", http.StatusSeeOther)
+}
+
+func (app *application) renderReview(w http.ResponseWriter, r *http.Request, status int, post *models.Post, form *reviewForm) {
+	reviews, err := app.posts.Reviews(post.Id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Post = post
+	data.Reviews = reviews
+	data.Form = form
+	app.renderTemplate(w, r, status, "review.html", data)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestReviewWorkflow(t *testing.T) {
+	app := newTestApplication(t)
+	app.config.RequireReview = true
+
+	author := newTestServer(t, app.routes())
+	reader := newTestServer(t, app.routes())
+	editor := newTestServer(t, app.routes())
+
+	author.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("title", "Needs review")
+	form.Add("content", "Waiting for an editor")
+	form.Add("csrf_token", author.csrfToken(t, "/post/add"))
+
+	code, header, _ := author.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/post/view/1")
+
+	_, _, body := author.get(t, "/post/view/1")
+	assert.StringContains(t, body, "This post is waiting for review")
+	assert.StringContains(t, body, "submitted for review")
+
+	code, _, _ = reader.get(t, "/post/view/1")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	_, _, body = reader.get(t, "/")
+	assert.Equal(t, strings.Contains(body, "Needs review"), false)
+
+	reader.login(t, app, "dave", "pa$$word")
+
+	code, _, _ = reader.get(t, "/review")
+	assert.Equal(t, code, http.StatusForbidden)
+
+	csrf := url.Values{}
+	csrf.Add("csrf_token", reader.csrfToken(t, "/post/add"))
+
+	code, _, _ = reader.postForm(t, "/post/withdraw/1", csrf)
+	assert.Equal(t, code, http.StatusForbidden)
+
+	editor.login(t, app, "carol", "pa$$word")
+
+	carol, err := app.users.GetByUsername("carol")
+	assert.NilError(t, err)
+	assert.NilError(t, app.users.SetRole(carol.Id, models.RoleEditor))
+
+	_, _, body = editor.get(t, "/review")
+	assert.StringContains(t, body, "Needs review")
+
+	review := url.Values{}
+	review.Add("action", models.ReviewRequestChanges)
+	review.Add("csrf_token", editor.csrfToken(t, "/review/1"))
+
+	code, _, body = editor.postForm(t, "/review/1", review)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "Explain what should be changed")
+
+	review.Set("note", "Please add a conclusion")
+	code, header, _ = editor.postForm(t, "/review/1", review)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/review")
+
+	code, _, _ = editor.get(t, "/review/1")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	_, _, body = author.get(t, "/post/view/1")
+	assert.StringContains(t, body, "A reviewer has asked for changes")
+	assert.StringContains(t, body, "Please add a conclusion")
+
+	_, _, body = author.get(t, "/notifications")
+	assert.StringContains(t, body, "carol asked for changes to “Needs review”")
+
+	csrf.Set("csrf_token", author.csrfToken(t, "/post/view/1"))
+
+	code, _, _ = author.postForm(t, "/post/withdraw/1", csrf)
+	assert.Equal(t, code, http.StatusConflict)
+
+	code, _, _ = author.postForm(t, "/post/submit/1", csrf)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	review.Set("action", models.ReviewApprove)
+	review.Set("note", "")
+	code, _, _ = editor.postForm(t, "/review/1", review)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = reader.get(t, "/post/view/1")
+	assert.StringContains(t, body, "Waiting for an editor")
+
+	post, err := app.posts.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Status, models.PostStatusPublished)
+
+	// Edits to published posts go back to the review queue.
+	form.Set("content", "Rewritten after approval")
+	form.Set("csrf_token", author.csrfToken(t, "/post/edit/1"))
+	code, _, _ = author.postForm(t, "/post/edit/1", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = author.get(t, "/post/view/1")
+	assert.StringContains(t, body, "resubmitted changes for review")
+
+	code, _, _ = reader.get(t, "/post/view/1")
+	assert.Equal(t, code, http.StatusNotFound)
+
+	_, _, body = editor.get(t, "/review")
+	assert.StringContains(t, body, "Needs review")
+
+	// Reviewers publish their own posts directly.
+	form.Set("title", "Editor's post")
+	form.Set("csrf_token", editor.csrfToken(t, "/post/add"))
+	editor.postForm(t, "/post/add", form)
+
+	post, err = app.posts.Get(2)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Status, models.PostStatusPublished)
+}
+
+func TestReviewHidesPrivatePosts(t *testing.T) {
+	app := newTestApplication(t)
+	app.config.RequireReview = true
+
+	author := newTestServer(t, app.routes())
+	editor := newTestServer(t, app.routes())
+
+	author.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("title", "Dear diary")
+	form.Add("content", "Nobody else should read this")
+	form.Add("visibility", models.PostVisibilityPrivate)
+	form.Add("csrf_token", author.csrfToken(t, "/post/add"))
+
+	code, header, _ := author.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/post/view/1")
+
+	post, err := app.posts.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Status, models.PostStatusInReview)
+
+	editor.login(t, app, "carol", "pa$$word")
+
+	carol, err := app.users.GetByUsername("carol")
+	assert.NilError(t, err)
+	assert.NilError(t, app.users.SetRole(carol.Id, models.RoleEditor))
+
+	_, _, body := editor.get(t, "/review")
+	assert.Equal(t, strings.Contains(body, "Dear diary"), false)
+
+	code, _, body = editor.get(t, "/review/1")
+	assert.Equal(t, code, http.StatusNotFound)
+	assert.Equal(t, strings.Contains(body, "Nobody else should read this"), false)
+
+	review := url.Values{}
+	review.Add("action", models.ReviewApprove)
+	review.Add("csrf_token", editor.csrfToken(t, "/post/add"))
+
+	code, _, _ = editor.postForm(t, "/review/1", review)
+	assert.Equal(t, code, http.StatusNotFound)
+}
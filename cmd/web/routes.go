@@ -3,24 +3,38 @@ package main
 import (
 	"net/http"
 
+	"github.com/anxxuj/microblog/internal/models"
 	"github.com/julienschmidt/httprouter"
 	"github.com/justinas/alice"
 )
 
 func (app *application) routes() http.Handler {
-	router := httprouter.New()
+	router := labeledRouter{httprouter.New()}
 
-	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		app.notFound(w)
-	})
+	router.NotFound = http.HandlerFunc(app.notFound)
+	router.MethodNotAllowed = http.HandlerFunc(app.methodNotAllowed)
 
-	fileServer := http.FileServer(http.Dir("./ui/static/"))
-	router.Handler(http.MethodGet, "/static/*filepath", http.StripPrefix("/static", fileServer))
+	router.Handler(http.MethodGet, "/static/*filepath", http.StripPrefix("/static", app.assets))
 
-	dynamic := alice.New(app.sessionManager.LoadAndSave, noSurf, app.authenticate)
+	router.HandlerFunc(http.MethodGet, "/healthz", app.healthz)
+	router.HandlerFunc(http.MethodGet, "/readyz", app.readyz)
+	router.HandlerFunc(http.MethodGet, "/sitemap.xml", app.sitemap)
+	router.HandlerFunc(http.MethodGet, "/robots.txt", app.robots)
+
+	dynamic := alice.New(app.sessionManager.LoadAndSave, app.noSurf, app.authenticate, app.localize)
 
 	router.Handler(http.MethodGet, "/", dynamic.ThenFunc(app.index))
 	router.Handler(http.MethodGet, "/post/view/:id", dynamic.ThenFunc(app.postView))
+	router.Handler(http.MethodGet, "/p/:slug", dynamic.ThenFunc(app.postBySlug))
+	router.Handler(http.MethodPost, "/post/unlock/:id", dynamic.ThenFunc(app.postUnlockPost))
+	router.Handler(http.MethodGet, "/series/:slug", dynamic.ThenFunc(app.seriesView))
+	router.Handler(http.MethodGet, "/archive", dynamic.ThenFunc(app.archiveIndex))
+	router.Handler(http.MethodGet, "/archive/:year", dynamic.ThenFunc(app.archiveYearView))
+	router.Handler(http.MethodGet, "/archive/:year/:month", dynamic.ThenFunc(app.archiveMonthView))
+	router.Handler(http.MethodGet, "/hashtag/:tag", dynamic.ThenFunc(app.hashtagView))
+	router.Handler(http.MethodGet, "/u/:username", dynamic.ThenFunc(app.userProfile))
+	router.Handler(http.MethodGet, "/u/:username/followers", dynamic.ThenFunc(app.userFollowers))
+	router.Handler(http.MethodGet, "/u/:username/following", dynamic.ThenFunc(app.userFollowing))
 	router.Handler(http.MethodGet, "/user/login", dynamic.ThenFunc(app.userLogin))
 	router.Handler(http.MethodPost, "/user/login", dynamic.ThenFunc(app.userLoginPost))
 	router.Handler(http.MethodGet, "/user/register", dynamic.ThenFunc(app.userRegister))
@@ -28,14 +42,64 @@ func (app *application) routes() http.Handler {
 
 	protected := dynamic.Append(app.requireAuthentication)
 
+	router.Handler(http.MethodGet, "/following", protected.ThenFunc(app.timeline))
+	router.Handler(http.MethodGet, "/notifications", protected.ThenFunc(app.notificationList))
+	router.Handler(http.MethodPost, "/notifications/read", protected.ThenFunc(app.notificationsReadPost))
+	router.Handler(http.MethodPost, "/u/:username/follow", protected.ThenFunc(app.userFollowPost))
+	router.Handler(http.MethodPost, "/u/:username/unfollow", protected.ThenFunc(app.userUnfollowPost))
 	router.Handler(http.MethodGet, "/post/add", protected.ThenFunc(app.postAdd))
 	router.Handler(http.MethodPost, "/post/add", protected.ThenFunc(app.postAddPost))
 	router.Handler(http.MethodGet, "/post/edit/:id", protected.ThenFunc(app.postEdit))
 	router.Handler(http.MethodPost, "/post/edit/:id", protected.ThenFunc(app.postEditPost))
-	router.Handler(http.MethodGet, "/post/delete/:id", protected.ThenFunc(app.postDelete))
+	router.Handler(http.MethodPost, "/post/delete/:id", protected.ThenFunc(app.postDeletePost))
+	router.Handler(http.MethodPost, "/post/submit/:id", protected.ThenFunc(app.postSubmitPost))
+	router.Handler(http.MethodPost, "/post/withdraw/:id", protected.ThenFunc(app.postWithdrawPost))
+	router.Handler(http.MethodPost, "/post/react/:id", protected.ThenFunc(app.postReactPost))
+	router.Handler(http.MethodGet, "/trash", protected.ThenFunc(app.trash))
+	router.Handler(http.MethodPost, "/trash/restore/:id", protected.ThenFunc(app.trashRestorePost))
+	router.Handler(http.MethodPost, "/trash/delete/:id", protected.ThenFunc(app.trashDeletePost))
+	router.Handler(http.MethodGet, "/user/export", protected.ThenFunc(app.userExportPosts))
+	router.Handler(http.MethodGet, "/user/settings", protected.ThenFunc(app.userSettings))
+	router.Handler(http.MethodPost, "/user/settings", protected.ThenFunc(app.userSettingsPost))
 	router.Handler(http.MethodGet, "/user/logout", protected.ThenFunc(app.userLogout))
 
-	standard := alice.New(app.recoverPanic, app.logRequest, secureHeaders)
+	reviewer := protected.Append(app.requirePermission(models.PermissionReview))
+
+	router.Handler(http.MethodGet, "/review", reviewer.ThenFunc(app.reviewQueue))
+	router.Handler(http.MethodGet, "/review/:id", reviewer.ThenFunc(app.review))
+	router.Handler(http.MethodPost, "/review/:id", reviewer.ThenFunc(app.reviewPost))
+
+	admin := protected.Append(app.requirePermission(models.PermissionAdmin))
+
+	router.Handler(http.MethodGet, "/admin", admin.ThenFunc(app.adminDashboard))
+	router.Handler(http.MethodGet, "/admin/backup", admin.ThenFunc(app.adminBackup))
+	router.Handler(http.MethodGet, "/admin/users", admin.ThenFunc(app.adminUsers))
+	router.Handler(http.MethodGet, "/admin/users/:id", admin.ThenFunc(app.adminUser))
+	router.Handler(http.MethodPost, "/admin/users/:id/disable", admin.ThenFunc(app.adminUserDisablePost))
+	router.Handler(http.MethodPost, "/admin/users/:id/enable", admin.ThenFunc(app.adminUserEnablePost))
+	router.Handler(http.MethodPost, "/admin/users/:id/role", admin.ThenFunc(app.adminUserRolePost))
+	router.Handler(http.MethodPost, "/admin/users/:id/password", admin.ThenFunc(app.adminUserPasswordPost))
+	router.Handler(http.MethodGet, "/admin/audit", admin.ThenFunc(app.adminAudit))
+	router.Handler(http.MethodGet, "/admin/audit/export", admin.ThenFunc(app.adminAuditExport))
+	router.Handler(http.MethodGet, "/admin/posts", admin.ThenFunc(app.adminPosts))
+	router.Handler(http.MethodPost, "/admin/posts", admin.ThenFunc(app.adminPostsPost))
+
+	moderate := protected.Append(app.requirePermission(models.PermissionModerate))
+
+	router.Handler(http.MethodGet, "/admin/flagged", moderate.ThenFunc(app.adminFlagged))
+	router.Handler(http.MethodPost, "/admin/flagged/:id/approve", moderate.ThenFunc(app.adminFlaggedApprovePost))
+	router.Handler(http.MethodPost, "/admin/flagged/:id/reject", moderate.ThenFunc(app.adminFlaggedRejectPost))
+
+	standard := alice.New(app.requestID, app.logRequest, app.recoverPanic, app.secureHeaders)
 
 	return standard.Then(router)
 }
+
+// metricsRoutes serves the Prometheus metrics on their own listener, so
+// that they aren't exposed on the public site.
+func (app *application) metricsRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", app.metrics.handler())
+
+	return mux
+}
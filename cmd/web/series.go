@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/anxxuj/microblog/internal/importer"
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+// seriesNav places a post within its series: it is part Part of Parts,
+// between Prev and Next, which are nil at either end.
+type seriesNav struct {
+	Series *models.Series
+	Part   int
+	Parts  int
+	Prev   *models.Post
+	Next   *models.Post
+}
+
+// seriesParts returns the posts of a series that the user making the
+// request may see, in order.
+func (app *application) seriesParts(r *http.Request, seriesId int) ([]*models.Post, error) {
+	posts, err := app.posts.GetBySeries(seriesId)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []*models.Post{}
+	for _, post := range posts {
+		if app.canView(r, post) {
+			parts = append(parts, post)
+		}
+	}
+
+	return parts, nil
+}
+
+// newSeriesNav returns the series navigation for a post, or nil if the
+// post isn't part of a series. Parts are numbered among those the reader
+// can see, so there are no gaps for drafts or private posts.
+func (app *application) newSeriesNav(r *http.Request, post *models.Post) (*seriesNav, error) {
+	if post.SeriesId == 0 {
+		return nil, nil
+	}
+
+	series, err := app.series.Get(post.SeriesId)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := app.seriesParts(r, series.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, part := range parts {
+		if part.Id != post.Id {
+			continue
+		}
+
+		nav := &seriesNav{Series: series, Part: i + 1, Parts: len(parts)}
+		if i > 0 {
+			nav.Prev = parts[i-1]
+		}
+		if i < len(parts)-1 {
+			nav.Next = parts[i+1]
+		}
+
+		return nav, nil
+	}
+
+	return nil, nil
+}
+
+// seriesView is the landing page of a series, listing all its parts.
+func (app *application) seriesView(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	series, err := app.series.GetBySlug(params.ByName("slug"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	parts, err := app.seriesParts(r, series.Id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Series = series
+	data.Posts = parts
+	app.renderTemplate(w, r, http.StatusOK, "series.html", data)
+}
+
+// seriesOptions returns the series a post by userId can be added to: the
+// series started by that user, plus current, the series the post is in
+// now, if it isn't one of them.
+func (app *application) seriesOptions(userId, current int) ([]*models.Series, error) {
+	options, err := app.series.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if current == 0 || slices.ContainsFunc(options, func(s *models.Series) bool { return s.Id == current }) {
+		return options, nil
+	}
+
+	series, err := app.series.Get(current)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(options, series), nil
+}
+
+// formSeries returns the series and part chosen on the post form. If the
+// form starts a new series, it returns that series for Save to create
+// along with the post instead. Its slug is made from the title; Save adds
+// a number if another series already uses it.
+func formSeries(form *postForm) (int, int, *models.Series) {
+	seriesId := 0
+	var series *models.Series
+
+	switch form.Series {
+	case "":
+	case newSeries:
+		slug := importer.Slugify(form.NewSeries)
+		if slug == "" {
+			slug = "series"
+		}
+		series = &models.Series{Slug: slug, Title: form.NewSeries}
+	default:
+		seriesId, _ = strconv.Atoi(form.Series)
+	}
+
+	part, _ := strconv.Atoi(form.Part)
+
+	return seriesId, part, series
+}
+
+// auditSeries records that series was started, if the post form started
+// one.
+func (app *application) auditSeries(r *http.Request, series *models.Series) {
+	if series == nil {
+		return
+	}
+
+	app.audit(r, &models.AuditEvent{Action: models.AuditSeriesCreate, TargetType: "series", TargetId: series.Id},
+		nil, map[string]string{"slug": series.Slug, "title": series.Title})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestSeries(t *testing.T) {
+	app := newTestApplication(t)
+	author := newTestServer(t, app.routes())
+	reader := newTestServer(t, app.routes())
+
+	author.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("title", "Getting started")
+	form.Add("content", "Install Go")
+	form.Add("series", "new")
+	form.Add("csrf_token", author.csrfToken(t, "/post/add"))
+
+	code, _, body := author.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "This field cannot be empty")
+
+	form.Set("newSeries", "Learning Go")
+	code, _, _ = author.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	series, err := app.series.GetBySlug("learning-go")
+	assert.NilError(t, err)
+
+	form.Set("series", "99")
+	form.Set("title", "Types")
+	code, _, body = author.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "Choose one of your series")
+
+	form.Set("series", "1")
+	form.Set("part", "0")
+	code, _, body = author.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "must be a whole number greater than zero")
+
+	// Posts without a part are added after the last one.
+	form.Del("part")
+	author.postForm(t, "/post/add", form)
+
+	form.Set("title", "Wrap-up")
+	form.Set("part", "9")
+	author.postForm(t, "/post/add", form)
+
+	form.Set("title", "Concurrency")
+	form.Set("part", "3")
+	form.Set("visibility", "private")
+	author.postForm(t, "/post/add", form)
+
+	_, _, body = reader.get(t, "/series/learning-go")
+	assert.StringContains(t, body, series.Title)
+	assert.StringContains(t, body, "3 parts")
+	assert.Equal(t, strings.Index(body, "Getting started") < strings.Index(body, "Types"), true)
+	assert.Equal(t, strings.Index(body, "Types") < strings.Index(body, "Wrap-up"), true)
+	assert.Equal(t, strings.Contains(body, "Concurrency"), false)
+
+	_, _, body = reader.get(t, "/post/view/2")
+	assert.StringContains(t, body, "Part 2 of 3")
+	assert.StringContains(t, body, `href="/series/learning-go"`)
+	assert.StringContains(t, body, "Previous: Getting started")
+	assert.StringContains(t, body, "Next: Wrap-up")
+
+	_, _, body = author.get(t, "/post/view/2")
+	assert.StringContains(t, body, "Part 2 of 4")
+	assert.StringContains(t, body, "Next: Concurrency")
+
+	_, _, body = reader.get(t, "/post/view/1")
+	assert.StringContains(t, body, "Part 1 of 3")
+	assert.Equal(t, strings.Contains(body, "Previous:"), false)
+
+	// Taking a post out of its series.
+	edit := url.Values{}
+	edit.Add("title", "Getting started")
+	edit.Add("content", "Install Go")
+	edit.Add("series", "")
+	edit.Add("csrf_token", author.csrfToken(t, "/post/edit/1"))
+
+	code, _, _ = author.postForm(t, "/post/edit/1", edit)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = reader.get(t, "/post/view/1")
+	assert.Equal(t, strings.Contains(body, "Part 1"), false)
+
+	_, _, body = reader.get(t, "/series/learning-go")
+	assert.StringContains(t, body, "2 parts")
+
+	// Starting a series with a title that's already taken numbers its slug.
+	edit.Set("series", "new")
+	edit.Set("newSeries", "Learning Go")
+	code, _, _ = author.postForm(t, "/post/edit/1", edit)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = reader.get(t, "/post/view/1")
+	assert.StringContains(t, body, `href="/series/learning-go-2"`)
+	assert.StringContains(t, body, "Part 1 of 1")
+
+	events, err := app.auditLog.List(models.AuditFilter{Action: models.AuditSeriesCreate})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, string(events[0].After), `{"slug":"learning-go-2","title":"Learning Go"}`)
+
+	code, _, _ = reader.get(t, "/series/unknown")
+	assert.Equal(t, code, http.StatusNotFound)
+}
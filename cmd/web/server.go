@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// serve runs the HTTP(S) server, and the optional HTTP to HTTPS redirect
+// server, until SIGINT or SIGTERM is received. It then stops accepting
+// connections, waits for in-flight requests and background jobs to finish
+// and returns.
+func (app *application) serve() error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	srv := &http.Server{
+		Addr:         app.config.Addr,
+		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
+		Handler:      app.routes(),
+		ReadTimeout:  app.config.ReadTimeout,
+		WriteTimeout: app.config.WriteTimeout,
+		IdleTimeout:  app.config.IdleTimeout,
+	}
+
+	servers := []*http.Server{srv}
+
+	if app.config.tlsEnabled() {
+		certs, err := newCertReloader(app.config.TLSCert, app.config.TLSKey)
+		if err != nil {
+			return err
+		}
+
+		srv.TLSConfig = &tls.Config{
+			GetCertificate: certs.getCertificate,
+			MinVersion:     tls.VersionTLS12,
+		}
+
+		if app.config.TLSReloadInterval > 0 {
+			app.background(func() {
+				certs.watch(jobsCtx, app.config.TLSReloadInterval, app.logger)
+			})
+		}
+
+		if app.config.RedirectAddr != "" {
+			servers = append(servers, &http.Server{
+				Addr:         app.config.RedirectAddr,
+				ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
+				Handler:      app.redirectToHTTPS(),
+				ReadTimeout:  app.config.ReadTimeout,
+				WriteTimeout: app.config.WriteTimeout,
+				IdleTimeout:  app.config.IdleTimeout,
+			})
+		}
+	}
+
+	if app.config.MetricsAddr != "" {
+		servers = append(servers, &http.Server{
+			Addr:         app.config.MetricsAddr,
+			ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
+			Handler:      app.metricsRoutes(),
+			ReadTimeout:  app.config.ReadTimeout,
+			WriteTimeout: app.config.WriteTimeout,
+			IdleTimeout:  app.config.IdleTimeout,
+		})
+	}
+
+	app.background(func() {
+		app.purgeTrash(jobsCtx, time.Hour)
+	})
+
+	serveErr := make(chan error, len(servers))
+
+	for i, s := range servers {
+		go func() {
+			var err error
+			if i == 0 && app.config.tlsEnabled() {
+				app.logger.Info("server starting", "addr", s.Addr, "tls", true)
+				err = s.ListenAndServeTLS("", "")
+			} else {
+				app.logger.Info("server starting", "addr", s.Addr, "tls", false)
+				err = s.ListenAndServe()
+			}
+			if !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- err
+			}
+		}()
+	}
+
+	var err error
+
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		app.logger.Info("shutting down server")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), app.config.ShutdownTimeout)
+	defer cancel()
+
+	for _, s := range servers {
+		if shutdownErr := s.Shutdown(shutdownCtx); shutdownErr != nil && err == nil {
+			err = shutdownErr
+		}
+	}
+
+	cancelJobs()
+	app.wg.Wait()
+
+	app.logger.Info("server stopped")
+
+	return err
+}
+
+// background runs fn in a goroutine tracked by app.wg so that serve can
+// wait for it during shutdown.
+func (app *application) background(fn func()) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+		fn()
+	}()
+}
+
+func (app *application) redirectToHTTPS() http.Handler {
+	_, port, _ := net.SplitHostPort(app.config.Addr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		w.Header().Set("Connection", "close")
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
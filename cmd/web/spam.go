@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/anxxuj/microblog/internal/spam"
+)
+
+// honeypotField is the name of a text input hidden from people with CSS.
+// Bots that fill in every field give themselves away by submitting it.
+const honeypotField = "website"
+
+// startForm remembers when a form was shown so that looksHuman can tell
+// how quickly it was filled in.
+func (app *application) startForm(r *http.Request, form string) {
+	app.sessionManager.Put(r.Context(), "formStarted."+form, time.Now().UnixNano())
+}
+
+// finishForm forgets when a form was shown after it has been submitted
+// successfully, so that each submission needs a fresh copy of the form.
+func (app *application) finishForm(r *http.Request, form string) {
+	app.sessionManager.Remove(r.Context(), "formStarted."+form)
+}
+
+// looksHuman reports whether a submission of form passes the honeypot and
+// timing checks. r.PostForm must already be parsed.
+func (app *application) looksHuman(r *http.Request, form string) bool {
+	reason := ""
+
+	if r.PostForm.Get(honeypotField) != "" {
+		reason = "honeypot"
+	} else if app.config.MinFormTime > 0 {
+		started := app.sessionManager.GetInt64(r.Context(), "formStarted."+form)
+		if started == 0 || time.Since(time.Unix(0, started)) < app.config.MinFormTime {
+			reason = "too fast"
+		}
+	}
+
+	if reason == "" {
+		return true
+	}
+
+	app.logger.Warn("rejected automated submission",
+		"request_id", requestInfoFromRequest(r).id,
+		"form", form,
+		"reason", reason,
+	)
+
+	return false
+}
+
+// postRateLimited reports whether the logged in user has used up their
+// posts for the current window. Moderators aren't limited.
+func (app *application) postRateLimited(r *http.Request) (bool, error) {
+	user := app.authenticatedUser(r)
+	if app.config.PostRateLimit == 0 || user.Can(models.PermissionModerate) {
+		return false, nil
+	}
+
+	n, err := app.posts.CountSince(user.Id, time.Now().Add(-app.config.PostRateWindow))
+	if err != nil {
+		return false, err
+	}
+
+	return n >= app.config.PostRateLimit, nil
+}
+
+// classifyPost runs the spam classifier over a post written by the logged
+// in user. Posts by moderators are never spam. If the classifier fails the
+// post is held for review rather than published unchecked.
+func (app *application) classifyPost(r *http.Request, title, content string) spam.Verdict {
+	if app.authenticatedUser(r).Can(models.PermissionModerate) {
+		return spam.Verdict{}
+	}
+
+	verdict, err := app.classifier.Classify(spam.Content{Title: title, Body: content})
+	if err != nil {
+		app.logger.Error("classifying post", "request_id", requestInfoFromRequest(r).id, "error", err)
+		return spam.Verdict{Spam: true, Reasons: []string{"classifier unavailable"}}
+	}
+
+	return verdict
+}
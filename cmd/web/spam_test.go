@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestRegisterAntiSpam(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	form := url.Values{}
+	form.Add("username", "bobby")
+	form.Add("email", "bob@mailinator.com")
+	form.Add("password", "validPa$$word")
+	form.Add("confirm-password", "validPa$$word")
+	form.Add("csrf_token", ts.csrfToken(t, "/user/register"))
+
+	code, _, body := ts.postForm(t, "/user/register", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "Disposable email addresses are not allowed")
+
+	form.Set("email", "bob@example.com")
+	form.Set(honeypotField, "http://spam.example")
+
+	code, _, body = ts.postForm(t, "/user/register", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+	assert.StringContains(t, body, "Please check your details")
+
+	form.Del(honeypotField)
+	app.config.MinFormTime = time.Hour
+
+	code, _, _ = ts.postForm(t, "/user/register", form)
+	assert.Equal(t, code, http.StatusUnprocessableEntity)
+
+	app.config.MinFormTime = 0
+
+	code, _, _ = ts.postForm(t, "/user/register", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+}
+
+func TestPostAntiSpam(t *testing.T) {
+	app := newTestApplication(t)
+	app.config.PostRateLimit = 2
+
+	ts := newTestServer(t, app.routes())
+	ts.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("title", "Free money")
+	form.Add("content", "Win free money at our casino: https://a.example https://b.example")
+	form.Add("csrf_token", ts.csrfToken(t, "/post/add"))
+
+	code, header, _ := ts.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/post/view/1")
+
+	post, err := app.posts.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Status, models.PostStatusFlagged)
+
+	code, _, body := ts.get(t, "/post/view/1")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "awaiting review by a moderator")
+	assert.StringContains(t, body, "held back by the spam filter")
+
+	_, _, body = ts.get(t, "/")
+	assert.Equal(t, strings.Contains(body, "Free money"), false)
+
+	form.Set("title", "Hello")
+	form.Set("content", "An old silent pond")
+
+	code, _, _ = ts.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	code, _, body = ts.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusTooManyRequests)
+	assert.StringContains(t, body, "too many posts")
+
+	code, _, _ = ts.get(t, "/admin/flagged")
+	assert.Equal(t, code, http.StatusForbidden)
+
+	admin := newTestServer(t, app.routes())
+	admin.loginAdmin(t, app, "carol")
+
+	_, _, body = admin.get(t, "/admin/flagged")
+	assert.StringContains(t, body, "Free money")
+
+	csrf := url.Values{}
+	csrf.Add("csrf_token", admin.csrfToken(t, "/admin/flagged"))
+
+	code, _, _ = admin.postForm(t, "/admin/flagged/2/approve", csrf)
+	assert.Equal(t, code, http.StatusNotFound)
+
+	code, _, _ = admin.postForm(t, "/admin/flagged/1/approve", csrf)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	_, _, body = ts.get(t, "/")
+	assert.StringContains(t, body, "Free money")
+
+	reviews, err := app.posts.Reviews(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(reviews), 1)
+	assert.Equal(t, reviews[0].Action, models.ReviewRelease)
+}
+
+func TestReleaseFlaggedPostToReview(t *testing.T) {
+	app := newTestApplication(t)
+	app.config.RequireReview = true
+
+	ts := newTestServer(t, app.routes())
+	ts.login(t, app, "alice", "pa$$word")
+
+	form := url.Values{}
+	form.Add("title", "Free money")
+	form.Add("content", "Win free money at our casino: https://a.example https://b.example")
+	form.Add("csrf_token", ts.csrfToken(t, "/post/add"))
+
+	code, _, _ := ts.postForm(t, "/post/add", form)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	admin := newTestServer(t, app.routes())
+	admin.loginAdmin(t, app, "carol")
+
+	csrf := url.Values{}
+	csrf.Add("csrf_token", admin.csrfToken(t, "/admin/flagged"))
+
+	code, _, _ = admin.postForm(t, "/admin/flagged/1/approve", csrf)
+	assert.Equal(t, code, http.StatusSeeOther)
+
+	post, err := app.posts.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Status, models.PostStatusInReview)
+
+	reviews, err := app.posts.Reviews(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(reviews), 1)
+	assert.Equal(t, reviews[0].Action, models.ReviewReleaseToReview)
+
+	_, _, body := admin.get(t, "/review")
+	assert.StringContains(t, body, "Free money")
+}
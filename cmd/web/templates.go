@@ -4,39 +4,38 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"net/http"
-	"path/filepath"
+	"path"
 	"time"
 
+	"github.com/anxxuj/microblog/internal/i18n"
 	"github.com/anxxuj/microblog/internal/models"
 	"github.com/justinas/nosurf"
 )
 
-func humanDate(t time.Time) string {
-	return t.Format("02 Jan, 2006")
-}
-
 var functions = template.FuncMap{
-	"humanDate": humanDate,
+	"emoji":             emoji,
+	"notificationKinds": models.NotificationKinds,
+	"notificationLabel": notificationLabel,
+	"reactionLabel":     reactionLabel,
 }
 
-func newTemplateCache() (map[string]*template.Template, error) {
+// newTemplateCache parses every page in html/pages of fsys together with
+// the base layout and the partials in html/partials. static is made
+// available to the templates for building the URLs of static files.
+func newTemplateCache(fsys fs.FS, static func(string) string) (map[string]*template.Template, error) {
 	cache := map[string]*template.Template{}
 
-	pages, err := filepath.Glob("./ui/html/pages/*.html")
+	pages, err := fs.Glob(fsys, "html/pages/*.html")
 	if err != nil {
 		return nil, err
 	}
 
 	for _, page := range pages {
-		name := filepath.Base(page)
-
-		ts, err := template.New(name).Funcs(functions).ParseFiles("./ui/html/base.html")
-		if err != nil {
-			return nil, err
-		}
+		name := path.Base(page)
 
-		ts, err = ts.ParseFiles(page)
+		ts, err := template.New(name).Funcs(functions).Funcs(template.FuncMap{"static": static}).ParseFS(fsys, "html/base.html", "html/partials/*.html", page)
 		if err != nil {
 			return nil, err
 		}
@@ -48,35 +47,99 @@ func newTemplateCache() (map[string]*template.Template, error) {
 }
 
 type tempateData struct {
+	Archive         []*archiveYear
+	ArchiveMonth    time.Month
+	ArchiveYear     int
+	AuditActions    []string
+	AuditEvents     []*models.AuditEvent
+	CanEdit         bool
 	CSRFToken       string
+	Error           *errorPage
 	Flash           string
+	FollowCounts    *models.FollowCounts
+	FollowList      string
 	Form            any
+	Hashtag         string
+	IsAdmin         bool
 	IsAuthenticated bool
+	IsAuthor        bool
+	IsFollowing     bool
+	IsReviewer      bool
+	Languages       []i18n.Language
+	Localizer       *i18n.Localizer
+	Meta            *pageMeta
+	Notifications   []*models.Notification
+	OlderThan       int
 	Post            *models.Post
+	PostStats       *models.PostStats
+	Profile         *models.User
 	Posts           []*models.Post
+	Query           string
+	Reactions       []*models.ReactionCount
+	Reviews         []*models.Review
+	Roles           []string
+	Series          *models.Series
+	SeriesNav       *seriesNav
+	Sort            string
+	StaticExport    bool
+	TrashRetention  time.Duration
+	Unread          int
+	User            *models.User
+	UserStats       *models.UserStats
+	Users           []*models.User
 }
 
 func (app *application) newTemplateData(r *http.Request) *tempateData {
+	localizer := app.localizer(r)
+
 	return &tempateData{
 		CSRFToken:       nosurf.Token(r),
-		Flash:           app.sessionManager.PopString(r.Context(), "flash"),
+		Flash:           localizer.T(app.sessionManager.PopString(r.Context(), "flash")),
+		IsAdmin:         app.authenticatedUser(r).Can(models.PermissionAdmin),
 		IsAuthenticated: app.isAuthenticated(r),
+		IsReviewer:      app.authenticatedUser(r).Can(models.PermissionReview),
+		Localizer:       localizer,
+		Meta:            app.newPageMeta(r),
+		Unread:          app.unreadNotifications(r),
 	}
 }
 
-func (app *application) renderTemplate(w http.ResponseWriter, status int, page string, data *tempateData) {
-	ts, ok := app.templateCache[page]
-	if !ok {
-		err := fmt.Errorf("the template %s does not exist", page)
-		app.serverError(w, err)
-		return
+// T, N, Date and Month let templates translate text and format dates with
+// the request's localizer, e.g. {{.T "Home"}} or {{$.Date .Created}}.
+
+func (data *tempateData) T(msg string, args ...any) string {
+	return data.Localizer.T(msg, args...)
+}
+
+func (data *tempateData) N(n int, one, other string, args ...any) string {
+	return data.Localizer.N(n, one, other, args...)
+}
+
+func (data *tempateData) Date(t time.Time) string {
+	return data.Localizer.Date(t)
+}
+
+func (data *tempateData) Month(year int, month time.Month) string {
+	return data.Localizer.Month(year, month)
+}
+
+// Linkify returns a post's content with its mentions and hashtags linked,
+// or as plain text in the static export, which has no pages to link to.
+func (data *tempateData) Linkify(post *models.Post) template.HTML {
+	if data.StaticExport {
+		return template.HTML(template.HTMLEscapeString(post.Content))
 	}
+	return linkify(post.Content, post.Mentions)
+}
 
-	buf := new(bytes.Buffer)
+func (data *tempateData) Lang() string {
+	return data.Localizer.Lang()
+}
 
-	err := ts.ExecuteTemplate(buf, "base", data)
+func (app *application) renderTemplate(w http.ResponseWriter, r *http.Request, status int, page string, data *tempateData) {
+	buf, err := app.executeTemplate(page, data)
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
@@ -84,3 +147,34 @@ func (app *application) renderTemplate(w http.ResponseWriter, status int, page s
 
 	buf.WriteTo(w)
 }
+
+// executeTemplate renders page with the base layout into a buffer. In
+// development mode the templates are re-parsed first so that changes are
+// picked up without a restart.
+func (app *application) executeTemplate(page string, data *tempateData) (*bytes.Buffer, error) {
+	cache := app.templateCache
+
+	if app.config.Dev {
+		var err error
+		cache, err = newTemplateCache(app.ui, app.assets.url)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ts, ok := cache[page]
+	if !ok {
+		return nil, fmt.Errorf("the template %s does not exist", page)
+	}
+
+	buf := new(bytes.Buffer)
+
+	start := time.Now()
+	err := ts.ExecuteTemplate(buf, "base", data)
+	app.metrics.renderDuration.WithLabelValues(page).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
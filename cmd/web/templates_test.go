@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/i18n"
+)
+
+func TestTemplateDataDate(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name      string
+		localizer *i18n.Localizer
+		tm        time.Time
+		want      string
+	}{
+		{"UTC", nil, time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC), "17 Mar, 2024"},
+		{"Zero", nil, time.Time{}, "01 Jan, 0001"},
+		{"German", i18n.New("de", nil), time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC), "17. März 2024"},
+		{"Japanese in Tokyo", i18n.New("ja", tokyo), time.Date(2024, 3, 17, 20, 15, 0, 0, time.UTC), "2024年3月18日"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &tempateData{Localizer: tt.localizer}
+			assert.Equal(t, data.Date(tt.tm), tt.want)
+		})
+	}
+}
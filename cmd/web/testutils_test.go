@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/anxxuj/microblog/internal/models"
+	"github.com/anxxuj/microblog/internal/models/mocks"
+	"github.com/anxxuj/microblog/internal/spam"
+	"github.com/anxxuj/microblog/ui"
+)
+
+// newTestApplication returns an application backed by the in-memory model
+// fakes and session store, with the embedded templates and static files.
+func newTestApplication(t *testing.T) *application {
+	t.Helper()
+
+	cfg := &config{
+		CSP:             "default-src 'self'",
+		PostRateWindow:  time.Hour,
+		SessionLifetime: 12 * time.Hour,
+		TrashRetention:  30 * 24 * time.Hour,
+	}
+
+	staticFS, err := fs.Sub(ui.Files, "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assets, err := newAssets(staticFS, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templateCache, err := newTemplateCache(ui.Files, assets.url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessionManager := scs.New()
+	sessionManager.Store = memstore.New()
+	sessionManager.Lifetime = cfg.SessionLifetime
+
+	users := &mocks.UserModel{}
+	series := &mocks.SeriesModel{}
+	posts := &mocks.PostModel{Users: users, Series: series}
+
+	return &application{
+		assets:         assets,
+		auditLog:       &mocks.AuditModel{},
+		classifier:     &spam.Heuristic{},
+		config:         cfg,
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		metrics:        newMetrics(nil),
+		notifications:  &mocks.NotificationModel{Users: users, Posts: posts},
+		posts:          posts,
+		series:         series,
+		sessionManager: sessionManager,
+		templateCache:  templateCache,
+		ui:             ui.Files,
+		users:          users,
+	}
+}
+
+// newPost saves a post by userId with the given title, content, status
+// and visibility and returns its ID.
+func newPost(t *testing.T, app *application, userId int, title, content, status, visibility string) int {
+	t.Helper()
+
+	post := &models.Post{UserId: userId, Title: title, Content: content, Status: status, Visibility: visibility}
+
+	_, err := app.posts.Save(post, nil, userId, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return post.Id
+}
+
+type testServer struct {
+	*httptest.Server
+}
+
+// newTestServer starts a server for h whose client keeps cookies between
+// requests and doesn't follow redirects.
+func newTestServer(t *testing.T, h http.Handler) *testServer {
+	t.Helper()
+
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts.Client().Jar = jar
+	ts.Client().CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &testServer{ts}
+}
+
+func (ts *testServer) get(t *testing.T, urlPath string) (int, http.Header, string) {
+	t.Helper()
+
+	rs, err := ts.Client().Get(ts.URL + urlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return readResponse(t, rs)
+}
+
+func (ts *testServer) postForm(t *testing.T, urlPath string, form url.Values) (int, http.Header, string) {
+	t.Helper()
+
+	rs, err := ts.Client().PostForm(ts.URL+urlPath, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return readResponse(t, rs)
+}
+
+// csrfToken fetches urlPath and returns the CSRF token embedded in its
+// form, which also sets the matching CSRF cookie on the client.
+func (ts *testServer) csrfToken(t *testing.T, urlPath string) string {
+	t.Helper()
+
+	_, _, body := ts.get(t, urlPath)
+	return extractCSRFToken(t, body)
+}
+
+// login registers a user directly with the model and logs them in through
+// the login form.
+func (ts *testServer) login(t *testing.T, app *application, username, password string) {
+	t.Helper()
+
+	err := app.users.Insert(username, username+"@example.com", password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{}
+	form.Add("username", username)
+	form.Add("password", password)
+	form.Add("csrf_token", ts.csrfToken(t, "/user/login"))
+
+	code, _, _ := ts.postForm(t, "/user/login", form)
+	if code != http.StatusSeeOther {
+		t.Fatalf("logging in as %q: got status %d", username, code)
+	}
+}
+
+func readResponse(t *testing.T, rs *http.Response) (int, http.Header, string) {
+	t.Helper()
+
+	defer rs.Body.Close()
+
+	body, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rs.StatusCode, rs.Header, string(bytes.TrimSpace(body))
+}
+
+var csrfTokenRX = regexp.MustCompile(`<input type="hidden" name="csrf_token" value="(.+)">`)
+
+func extractCSRFToken(t *testing.T, body string) string {
+	t.Helper()
+
+	matches := csrfTokenRX.FindStringSubmatch(body)
+	if len(matches) < 2 {
+		t.Fatal("no csrf token found in body")
+	}
+
+	return html.UnescapeString(matches[1])
+}
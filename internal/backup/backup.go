@@ -0,0 +1,491 @@
+// Package backup reads and writes versioned zip archives of all blog data
+// and Markdown exports of individual users' posts.
+package backup
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// Version is the version of the archive format written by Write. Read
+// accepts archives up to this version; the parts an older archive doesn't
+// have are left empty. Version 2 added review histories, version 3 series,
+// version 4 reactions, version 5 follows, version 6 notifications and
+// version 7 whether mentioned users were notified.
+const Version = 7
+
+type Manifest struct {
+	Version         int       `json:"version"`
+	Created         time.Time `json:"created"`
+	IncludesSecrets bool      `json:"includes_secrets"`
+	Users           int       `json:"users"`
+	Posts           int       `json:"posts"`
+	Tags            int       `json:"tags"`
+	Reviews         int       `json:"reviews"`
+	Series          int       `json:"series"`
+	Reactions       int       `json:"reactions"`
+	Follows         int       `json:"follows"`
+	Notifications   int       `json:"notifications"`
+	Mentions        int       `json:"mentions"`
+}
+
+type User struct {
+	Id           int    `json:"id"`
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"password_hash,omitempty"`
+	Role         string `json:"role,omitempty"`
+	Disabled     bool   `json:"disabled,omitempty"`
+	Language     string `json:"language,omitempty"`
+	TimeZone     string `json:"time_zone,omitempty"`
+}
+
+type Post struct {
+	Id           int        `json:"id"`
+	UserId       int        `json:"user_id,omitempty"`
+	Slug         string     `json:"slug,omitempty"`
+	Title        string     `json:"title"`
+	Content      string     `json:"content"`
+	Tags         []string   `json:"tags"`
+	Status       string     `json:"status,omitempty"`
+	Visibility   string     `json:"visibility,omitempty"`
+	PasswordHash string     `json:"password_hash,omitempty"`
+	SeriesId     int        `json:"series_id,omitempty"`
+	SeriesPart   int        `json:"series_part,omitempty"`
+	Meta         *PostMeta  `json:"meta,omitempty"`
+	Excerpt      string     `json:"excerpt,omitempty"`
+	CoverImage   string     `json:"cover_image,omitempty"`
+	Created      time.Time  `json:"created"`
+	Updated      *time.Time `json:"updated,omitempty"`
+	Deleted      *time.Time `json:"deleted,omitempty"`
+}
+
+// PostMeta holds a post's overrides for sharing and search engines.
+type PostMeta struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+}
+
+// Review is a step in a post's review history.
+type Review struct {
+	Id      int       `json:"id"`
+	PostId  int       `json:"post_id"`
+	ActorId int       `json:"actor_id,omitempty"`
+	Action  string    `json:"action"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Note    string    `json:"note,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+type Series struct {
+	Id      int       `json:"id"`
+	UserId  int       `json:"user_id,omitempty"`
+	Slug    string    `json:"slug"`
+	Title   string    `json:"title"`
+	Created time.Time `json:"created"`
+}
+
+type Reaction struct {
+	PostId  int       `json:"post_id"`
+	UserId  int       `json:"user_id"`
+	Kind    string    `json:"kind"`
+	Created time.Time `json:"created"`
+}
+
+type Follow struct {
+	FollowerId int       `json:"follower_id"`
+	FollowedId int       `json:"followed_id"`
+	Created    time.Time `json:"created"`
+}
+
+type Notification struct {
+	Id      int       `json:"id"`
+	UserId  int       `json:"user_id"`
+	ActorId int       `json:"actor_id,omitempty"`
+	Kind    string    `json:"kind"`
+	PostId  int       `json:"post_id,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+	Created time.Time `json:"created"`
+	Read    bool      `json:"read,omitempty"`
+}
+
+// Mute is the kinds of notifications a user has turned off.
+type Mute struct {
+	UserId int      `json:"user_id"`
+	Kinds  []string `json:"kinds"`
+}
+
+// Mention is a user mentioned in a post. Posts' mentions and hashtags are
+// found in their content again on restore; the archive only keeps who was
+// already notified.
+type Mention struct {
+	PostId   int  `json:"post_id"`
+	UserId   int  `json:"user_id"`
+	Notified bool `json:"notified,omitempty"`
+}
+
+type Archive struct {
+	Manifest      Manifest
+	Users         []*User
+	Posts         []*Post
+	Reviews       []*Review
+	Series        []*Series
+	Reactions     []*Reaction
+	Follows       []*Follow
+	Notifications []*Notification
+	Mutes         []*Mute
+	Mentions      []*Mention
+}
+
+// New builds an archive of users and posts. Password hashes are only
+// included if includeSecrets is true.
+func New(users []*models.User, posts []*models.Post, includeSecrets bool) *Archive {
+	a := &Archive{
+		Manifest: Manifest{
+			Version:         Version,
+			Created:         time.Now().UTC(),
+			IncludesSecrets: includeSecrets,
+		},
+		Users:         []*User{},
+		Posts:         []*Post{},
+		Reviews:       []*Review{},
+		Series:        []*Series{},
+		Reactions:     []*Reaction{},
+		Follows:       []*Follow{},
+		Notifications: []*Notification{},
+		Mutes:         []*Mute{},
+		Mentions:      []*Mention{},
+	}
+
+	for _, u := range users {
+		user := &User{Id: u.Id, Username: u.Username, Email: u.Email, Role: u.Role, Disabled: u.Disabled,
+			Language: u.Language, TimeZone: u.TimeZone}
+		if includeSecrets {
+			user.PasswordHash = string(u.PasswordHash)
+		}
+		a.Users = append(a.Users, user)
+	}
+
+	tags := map[string]bool{}
+
+	for _, p := range posts {
+		post := &Post{
+			Id:         p.Id,
+			UserId:     p.UserId,
+			Slug:       p.Slug,
+			Title:      p.Title,
+			Content:    p.Content,
+			Tags:       p.Tags,
+			Status:     p.Status,
+			Visibility: p.Visibility,
+			SeriesId:   p.SeriesId,
+			SeriesPart: p.SeriesPart,
+			Excerpt:    p.Excerpt,
+			CoverImage: p.CoverImage,
+			Created:    p.Created.UTC(),
+		}
+		if includeSecrets {
+			post.PasswordHash = string(p.PasswordHash)
+		}
+		if p.Meta != (models.PostMeta{}) {
+			post.Meta = &PostMeta{Title: p.Meta.Title, Description: p.Meta.Description, Image: p.Meta.Image}
+		}
+		if post.Tags == nil {
+			post.Tags = []string{}
+		}
+		if !p.Updated.IsZero() {
+			updated := p.Updated.UTC()
+			post.Updated = &updated
+		}
+		if !p.Deleted.IsZero() {
+			deleted := p.Deleted.UTC()
+			post.Deleted = &deleted
+		}
+		for _, tag := range p.Tags {
+			tags[tag] = true
+		}
+		a.Posts = append(a.Posts, post)
+	}
+
+	a.Manifest.Users = len(a.Users)
+	a.Manifest.Posts = len(a.Posts)
+	a.Manifest.Tags = len(tags)
+
+	return a
+}
+
+// AddReviews adds the review histories of posts to the archive.
+func (a *Archive) AddReviews(reviews []*models.Review) {
+	for _, r := range reviews {
+		a.Reviews = append(a.Reviews, &Review{Id: r.Id, PostId: r.PostId, ActorId: r.ActorId, Action: r.Action,
+			From: r.From, To: r.To, Note: r.Note, Created: r.Created.UTC()})
+	}
+
+	a.Manifest.Reviews = len(a.Reviews)
+}
+
+// AddSeries adds series to the archive.
+func (a *Archive) AddSeries(series []*models.Series) {
+	for _, s := range series {
+		a.Series = append(a.Series, &Series{Id: s.Id, UserId: s.UserId, Slug: s.Slug, Title: s.Title,
+			Created: s.Created.UTC()})
+	}
+
+	a.Manifest.Series = len(a.Series)
+}
+
+// AddReactions adds readers' reactions to posts to the archive.
+func (a *Archive) AddReactions(reactions []*models.Reaction) {
+	for _, r := range reactions {
+		a.Reactions = append(a.Reactions, &Reaction{PostId: r.PostId, UserId: r.UserId, Kind: r.Kind,
+			Created: r.Created.UTC()})
+	}
+
+	a.Manifest.Reactions = len(a.Reactions)
+}
+
+// AddFollows adds who follows whom to the archive.
+func (a *Archive) AddFollows(follows []*models.Follow) {
+	for _, f := range follows {
+		a.Follows = append(a.Follows, &Follow{FollowerId: f.FollowerId, FollowedId: f.FollowedId,
+			Created: f.Created.UTC()})
+	}
+
+	a.Manifest.Follows = len(a.Follows)
+}
+
+// AddNotifications adds users' notifications and the kinds of
+// notifications they turned off to the archive.
+func (a *Archive) AddNotifications(notifications []*models.Notification, muted map[int][]string) {
+	for _, n := range notifications {
+		a.Notifications = append(a.Notifications, &Notification{Id: n.Id, UserId: n.UserId, ActorId: n.ActorId,
+			Kind: n.Kind, PostId: n.PostId, Detail: n.Detail, Created: n.Created.UTC(), Read: n.Read})
+	}
+
+	for userId, kinds := range muted {
+		a.Mutes = append(a.Mutes, &Mute{UserId: userId, Kinds: kinds})
+	}
+	sort.Slice(a.Mutes, func(i, j int) bool { return a.Mutes[i].UserId < a.Mutes[j].UserId })
+
+	a.Manifest.Notifications = len(a.Notifications)
+}
+
+// AddMentions adds the users mentioned in posts to the archive.
+func (a *Archive) AddMentions(mentions []*models.Mention) {
+	for _, m := range mentions {
+		a.Mentions = append(a.Mentions, &Mention{PostId: m.PostId, UserId: m.UserId, Notified: m.Notified})
+	}
+
+	a.Manifest.Mentions = len(a.Mentions)
+}
+
+// Write writes the archive to w as a zip file.
+func (a *Archive) Write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"manifest.json", a.Manifest},
+		{"users.json", a.Users},
+		{"posts.json", a.Posts},
+		{"reviews.json", a.Reviews},
+		{"series.json", a.Series},
+		{"reactions.json", a.Reactions},
+		{"follows.json", a.Follows},
+		{"notifications.json", a.Notifications},
+		{"mutes.json", a.Mutes},
+		{"mentions.json", a.Mentions},
+	}
+
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: a.Manifest.Created,
+		})
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+
+		err = enc.Encode(f.v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// Read reads an archive written by Write.
+func Read(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+
+	a := &Archive{}
+
+	err = readJSON(zr, "manifest.json", &a.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Manifest.Version < 1 || a.Manifest.Version > Version {
+		return nil, fmt.Errorf("backup: unsupported archive version %d", a.Manifest.Version)
+	}
+
+	err = readJSON(zr, "users.json", &a.Users)
+	if err != nil {
+		return nil, err
+	}
+
+	err = readJSON(zr, "posts.json", &a.Posts)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Manifest.Version >= 2 {
+		err = readJSON(zr, "reviews.json", &a.Reviews)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.Manifest.Version >= 3 {
+		err = readJSON(zr, "series.json", &a.Series)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.Manifest.Version >= 4 {
+		err = readJSON(zr, "reactions.json", &a.Reactions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.Manifest.Version >= 5 {
+		err = readJSON(zr, "follows.json", &a.Follows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.Manifest.Version >= 6 {
+		err = readJSON(zr, "notifications.json", &a.Notifications)
+		if err != nil {
+			return nil, err
+		}
+
+		err = readJSON(zr, "mutes.json", &a.Mutes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.Manifest.Version >= 7 {
+		err = readJSON(zr, "mentions.json", &a.Mentions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+func readJSON(zr *zip.Reader, name string, v any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	defer f.Close()
+
+	err = json.NewDecoder(f).Decode(v)
+	if err != nil {
+		return fmt.Errorf("backup: %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func (u *User) Model() *models.User {
+	return &models.User{
+		Id:           u.Id,
+		Username:     u.Username,
+		Email:        u.Email,
+		PasswordHash: []byte(u.PasswordHash),
+		Role:         u.Role,
+		Disabled:     u.Disabled,
+		Language:     u.Language,
+		TimeZone:     u.TimeZone,
+	}
+}
+
+func (p *Post) Model() *models.Post {
+	post := &models.Post{
+		Id:           p.Id,
+		UserId:       p.UserId,
+		Slug:         p.Slug,
+		Title:        p.Title,
+		Content:      p.Content,
+		Tags:         p.Tags,
+		Status:       p.Status,
+		Visibility:   p.Visibility,
+		PasswordHash: []byte(p.PasswordHash),
+		SeriesId:     p.SeriesId,
+		SeriesPart:   p.SeriesPart,
+		Excerpt:      p.Excerpt,
+		CoverImage:   p.CoverImage,
+		Created:      p.Created,
+	}
+	if p.Meta != nil {
+		post.Meta = models.PostMeta{Title: p.Meta.Title, Description: p.Meta.Description, Image: p.Meta.Image}
+	}
+	if p.Updated != nil {
+		post.Updated = *p.Updated
+	}
+	if p.Deleted != nil {
+		post.Deleted = *p.Deleted
+	}
+	return post
+}
+
+func (r *Review) Model() *models.Review {
+	return &models.Review{Id: r.Id, PostId: r.PostId, ActorId: r.ActorId, Action: r.Action, From: r.From, To: r.To,
+		Note: r.Note, Created: r.Created}
+}
+
+func (s *Series) Model() *models.Series {
+	return &models.Series{Id: s.Id, UserId: s.UserId, Slug: s.Slug, Title: s.Title, Created: s.Created}
+}
+
+func (r *Reaction) Model() *models.Reaction {
+	return &models.Reaction{PostId: r.PostId, UserId: r.UserId, Kind: r.Kind, Created: r.Created}
+}
+
+func (f *Follow) Model() *models.Follow {
+	return &models.Follow{FollowerId: f.FollowerId, FollowedId: f.FollowedId, Created: f.Created}
+}
+
+func (n *Notification) Model() *models.Notification {
+	return &models.Notification{Id: n.Id, UserId: n.UserId, ActorId: n.ActorId, Kind: n.Kind, PostId: n.PostId,
+		Detail: n.Detail, Created: n.Created, Read: n.Read}
+}
+
+func (m *Mention) Model() *models.Mention {
+	return &models.Mention{PostId: m.PostId, UserId: m.UserId, Notified: m.Notified}
+}
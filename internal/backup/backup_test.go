@@ -0,0 +1,162 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+	"github.com/anxxuj/microblog/internal/importer"
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC)
+
+	users := []*models.User{
+		{Id: 1, Username: "alice", Email: "alice@example.com", PasswordHash: []byte("hash")},
+	}
+	posts := []*models.Post{
+		{Id: 2, UserId: 1, Slug: "two", Title: "Two", Content: "Second", Tags: []string{"go"}, Created: created,
+			Meta: models.PostMeta{Description: "The second post"}, Excerpt: "Two of two", CoverImage: "https://example.com/two.png"},
+		{Id: 1, UserId: 1, Title: "One", Content: "First", SeriesId: 3, SeriesPart: 1, Created: created,
+			Updated: created.Add(time.Minute), Deleted: created.Add(time.Hour)},
+	}
+	series := []*models.Series{
+		{Id: 3, UserId: 1, Slug: "learning-go", Title: "Learning Go", Created: created},
+	}
+	reviews := []*models.Review{
+		{Id: 4, PostId: 2, ActorId: 1, Action: models.ReviewSubmit, From: models.PostStatusDraft,
+			To: models.PostStatusInReview, Created: created},
+	}
+	reactions := []*models.Reaction{{PostId: 2, UserId: 1, Kind: models.ReactionLove, Created: created}}
+	follows := []*models.Follow{{FollowerId: 2, FollowedId: 1, Created: created}}
+	notifications := []*models.Notification{
+		{Id: 5, UserId: 1, ActorId: 2, Kind: models.NotificationFollow, Created: created, Read: true},
+	}
+	muted := map[int][]string{2: {models.NotificationReaction}}
+	mentions := []*models.Mention{{PostId: 2, UserId: 1, Notified: true}}
+
+	for _, includeSecrets := range []bool{false, true} {
+		var buf bytes.Buffer
+
+		a := New(users, posts, includeSecrets)
+		a.AddReviews(reviews)
+		a.AddSeries(series)
+		a.AddReactions(reactions)
+		a.AddFollows(follows)
+		a.AddNotifications(notifications, muted)
+		a.AddMentions(mentions)
+		assert.NilError(t, a.Write(&buf))
+
+		a, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		assert.NilError(t, err)
+
+		assert.Equal(t, a.Manifest.Version, Version)
+		assert.Equal(t, a.Manifest.Reviews, 1)
+		assert.Equal(t, *a.Reviews[0].Model(), *reviews[0])
+		assert.Equal(t, a.Manifest.Series, 1)
+		assert.Equal(t, *a.Series[0].Model(), *series[0])
+		assert.Equal(t, a.Manifest.Reactions, 1)
+		assert.Equal(t, *a.Reactions[0].Model(), *reactions[0])
+		assert.Equal(t, a.Manifest.Follows, 1)
+		assert.Equal(t, *a.Follows[0].Model(), *follows[0])
+		assert.Equal(t, a.Manifest.Notifications, 1)
+		assert.Equal(t, *a.Notifications[0].Model(), *notifications[0])
+		assert.Equal(t, len(a.Mutes), 1)
+		assert.Equal(t, a.Mutes[0].UserId, 2)
+		assert.Equal(t, strings.Join(a.Mutes[0].Kinds, ","), models.NotificationReaction)
+		assert.Equal(t, a.Manifest.Mentions, 1)
+		assert.Equal(t, *a.Mentions[0].Model(), *mentions[0])
+		assert.Equal(t, a.Manifest.IncludesSecrets, includeSecrets)
+		assert.Equal(t, a.Manifest.Tags, 1)
+		assert.Equal(t, len(a.Users), 1)
+		assert.Equal(t, len(a.Posts), 2)
+
+		wantHash := ""
+		if includeSecrets {
+			wantHash = "hash"
+		}
+		assert.Equal(t, a.Users[0].PasswordHash, wantHash)
+
+		post := a.Posts[1].Model()
+		assert.Equal(t, post.Title, "One")
+		assert.Equal(t, post.Created, created)
+		assert.Equal(t, post.Updated, created.Add(time.Minute))
+		assert.Equal(t, post.Deleted, created.Add(time.Hour))
+		assert.Equal(t, post.SeriesId, 3)
+		assert.Equal(t, post.SeriesPart, 1)
+		assert.Equal(t, post.Meta, models.PostMeta{})
+		assert.Equal(t, a.Posts[0].Model().Deleted.IsZero(), true)
+		assert.Equal(t, a.Posts[0].Model().Meta.Description, "The second post")
+		assert.Equal(t, a.Posts[0].Model().Excerpt, "Two of two")
+		assert.Equal(t, a.Posts[0].Model().CoverImage, "https://example.com/two.png")
+	}
+}
+
+func TestReadVersion1(t *testing.T) {
+	a := New(nil, nil, false)
+	a.Manifest.Version = 1
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, v := range map[string]any{"manifest.json": a.Manifest, "users.json": a.Users, "posts.json": a.Posts} {
+		fw, err := zw.Create(name)
+		assert.NilError(t, err)
+		assert.NilError(t, json.NewEncoder(fw).Encode(v))
+	}
+	assert.NilError(t, zw.Close())
+
+	read, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NilError(t, err)
+	assert.Equal(t, read.Manifest.Version, 1)
+	assert.Equal(t, len(read.Reviews), 0)
+	assert.Equal(t, len(read.Series), 0)
+	assert.Equal(t, len(read.Reactions), 0)
+	assert.Equal(t, len(read.Follows), 0)
+	assert.Equal(t, len(read.Notifications), 0)
+	assert.Equal(t, len(read.Mentions), 0)
+}
+
+func TestReadRejectsNewerVersion(t *testing.T) {
+	a := New(nil, nil, false)
+	a.Manifest.Version = Version + 1
+
+	var buf bytes.Buffer
+	assert.NilError(t, a.Write(&buf))
+
+	_, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Equal(t, err != nil, true)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	created := time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC)
+
+	posts := []*models.Post{
+		{Id: 1, Author: "alice", Slug: "hello", Title: "Hello", Content: "World", Tags: []string{"go"}, Created: created},
+		{Id: 2, Author: "alice", Title: "Hello", Content: "Again", Created: created},
+	}
+
+	var buf bytes.Buffer
+	assert.NilError(t, WriteMarkdown(&buf, posts))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NilError(t, err)
+
+	names, err := fs.Glob(zr, "*.md")
+	assert.NilError(t, err)
+	assert.Equal(t, strings.Join(names, ","), "hello-2.md,hello.md")
+
+	read, _, err := importer.ReadMarkdown(zr)
+	assert.NilError(t, err)
+	assert.Equal(t, len(read), 2)
+
+	for _, post := range read {
+		assert.Equal(t, post.Author, "alice")
+		assert.Equal(t, post.Created, created)
+	}
+}
@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/importer"
+	"github.com/anxxuj/microblog/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+type frontMatter struct {
+	Title  string    `yaml:"title"`
+	Author string    `yaml:"author,omitempty"`
+	Slug   string    `yaml:"slug,omitempty"`
+	Tags   []string  `yaml:"tags,omitempty"`
+	Date   time.Time `yaml:"date"`
+}
+
+// WriteMarkdown writes posts to w as a zip of Markdown files with YAML
+// front matter, in the format read by importer.ReadMarkdown.
+func WriteMarkdown(w io.Writer, posts []*models.Post) error {
+	zw := zip.NewWriter(w)
+	names := map[string]bool{}
+
+	for _, post := range posts {
+		base := post.Slug
+		if base == "" {
+			base = importer.Slugify(post.Title)
+		}
+		if base == "" {
+			base = "post"
+		}
+		if names[base] {
+			base = base + "-" + strconv.Itoa(post.Id)
+		}
+		names[base] = true
+
+		var buf bytes.Buffer
+
+		buf.WriteString("---\n")
+
+		err := yaml.NewEncoder(&buf).Encode(frontMatter{
+			Title:  post.Title,
+			Author: post.Author,
+			Slug:   post.Slug,
+			Tags:   post.Tags,
+			Date:   post.Created.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&buf, "---\n\n%s\n", post.Content)
+
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     base + ".md",
+			Method:   zip.Deflate,
+			Modified: post.Created,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = buf.WriteTo(fw)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
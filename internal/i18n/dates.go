@@ -0,0 +1,38 @@
+package i18n
+
+import (
+	"fmt"
+	"time"
+)
+
+var germanMonths = [...]string{
+	"Januar", "Februar", "März", "April", "Mai", "Juni",
+	"Juli", "August", "September", "Oktober", "November", "Dezember",
+}
+
+// Date formats the date of t in the localizer's time zone, e.g.
+// "17 Mar, 2024", "17. März 2024" or "2024年3月17日".
+func (l *Localizer) Date(t time.Time) string {
+	t = t.In(l.Location())
+
+	switch l.Lang() {
+	case "de":
+		return fmt.Sprintf("%d. %s %d", t.Day(), germanMonths[t.Month()-1], t.Year())
+	case "ja":
+		return fmt.Sprintf("%d年%d月%d日", t.Year(), t.Month(), t.Day())
+	default:
+		return t.Format("02 Jan, 2006")
+	}
+}
+
+// Month names a month, e.g. "March 2024", "März 2024" or "2024年3月".
+func (l *Localizer) Month(year int, month time.Month) string {
+	switch l.Lang() {
+	case "de":
+		return fmt.Sprintf("%s %d", germanMonths[month-1], year)
+	case "ja":
+		return fmt.Sprintf("%d年%d月", year, month)
+	default:
+		return fmt.Sprintf("%s %d", month, year)
+	}
+}
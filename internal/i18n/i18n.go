@@ -0,0 +1,183 @@
+// Package i18n translates user-facing text and formats dates for the
+// languages the blog supports.
+//
+// Messages are looked up by their English text, so untranslated messages
+// fall back to English. Catalogs live in locales/<language>.json and map
+// each message either to its translation or, for messages that depend on
+// a count, to an object with "one" and "other" forms.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// DefaultLanguage is used when negotiation finds no supported language.
+// It has no catalog because messages are written in it.
+const DefaultLanguage = "en"
+
+type Language struct {
+	Tag  string
+	Name string
+}
+
+var languages = []Language{
+	{"en", "English"},
+	{"de", "Deutsch"},
+	{"ja", "日本語"},
+}
+
+// Languages returns the supported languages, named in their own language.
+func Languages() []Language {
+	return languages
+}
+
+func Supported(tag string) bool {
+	for _, l := range languages {
+		if l.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var catalogs = mustLoadCatalogs()
+
+// message is a catalog entry. Messages that don't depend on a count only
+// have Other.
+type message struct {
+	One   string `json:"one"`
+	Other string `json:"other"`
+}
+
+func (m *message) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		m.Other = s
+		return nil
+	}
+
+	type forms message
+	return json.Unmarshal(b, (*forms)(m))
+}
+
+func mustLoadCatalogs() map[string]map[string]message {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	catalogs := map[string]map[string]message{}
+
+	for _, f := range files {
+		b, err := localeFiles.ReadFile("locales/" + f.Name())
+		if err != nil {
+			panic(err)
+		}
+
+		messages := map[string]message{}
+
+		err = json.Unmarshal(b, &messages)
+		if err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", f.Name(), err))
+		}
+
+		catalogs[strings.TrimSuffix(f.Name(), path.Ext(f.Name()))] = messages
+	}
+
+	return catalogs
+}
+
+// Localizer translates messages and formats dates for one language and
+// time zone. A nil *Localizer uses English and UTC.
+type Localizer struct {
+	lang     string
+	location *time.Location
+}
+
+// New returns a Localizer for lang, which must be supported, showing times
+// in loc. A nil loc means UTC.
+func New(lang string, loc *time.Location) *Localizer {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return &Localizer{lang: lang, location: loc}
+}
+
+func (l *Localizer) Lang() string {
+	if l == nil {
+		return DefaultLanguage
+	}
+	return l.lang
+}
+
+func (l *Localizer) Location() *time.Location {
+	if l == nil {
+		return time.UTC
+	}
+	return l.location
+}
+
+// T translates msg. If args are given the translation is used as a format
+// string for them.
+func (l *Localizer) T(msg string, args ...any) string {
+	if m, ok := catalogs[l.Lang()][msg]; ok && m.Other != "" {
+		msg = m.Other
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+
+	return msg
+}
+
+// N translates a message that depends on the count n, choosing the plural
+// form the language uses for n. The translation is used as a format
+// string for n followed by args, e.g. N(3, "%d post", "%d posts").
+// Catalogs list such messages under their other form.
+func (l *Localizer) N(n int, one, other string, args ...any) string {
+	msg := other
+	if plural(l.Lang(), n) == formOne {
+		msg = one
+	}
+
+	if m, ok := catalogs[l.Lang()][other]; ok {
+		if plural(l.Lang(), n) == formOne && m.One != "" {
+			msg = m.One
+		} else if m.Other != "" {
+			msg = m.Other
+		}
+	}
+
+	return fmt.Sprintf(msg, append([]any{n}, args...)...)
+}
+
+type pluralForm int
+
+const (
+	formOne pluralForm = iota
+	formOther
+)
+
+// plural returns the CLDR plural category of n in lang, for the categories
+// the supported languages use for integers.
+func plural(lang string, n int) pluralForm {
+	switch lang {
+	case "ja":
+		return formOther
+	default:
+		if n == 1 {
+			return formOne
+		}
+		return formOther
+	}
+}
@@ -0,0 +1,79 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"Empty", "", "en"},
+		{"Exact", "de", "de"},
+		{"Region", "de-AT,en;q=0.5", "de"},
+		{"Quality", "en;q=0.4, ja;q=0.9", "ja"},
+		{"Unsupported", "fr-CH, fr;q=0.9", "en"},
+		{"Skips unsupported", "fr, ja;q=0.8", "ja"},
+		{"Zero quality", "ja;q=0", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Negotiate(tt.header), tt.want)
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, New("de", nil).T("Home"), "Startseite")
+	assert.Equal(t, New("de", nil).T("by %s", "alice"), "von alice")
+	assert.Equal(t, New("de", nil).T("Not in the catalog"), "Not in the catalog")
+
+	var l *Localizer
+	assert.Equal(t, l.T("Home"), "Home")
+}
+
+func TestN(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		n    int
+		want string
+	}{
+		{"English one", "en", 1, "1 post in the trash"},
+		{"English other", "en", 2, "2 posts in the trash"},
+		{"English zero", "en", 0, "0 posts in the trash"},
+		{"German one", "de", 1, "1 Beitrag im Papierkorb"},
+		{"German other", "de", 5, "5 Beiträge im Papierkorb"},
+		{"Japanese", "ja", 1, "ゴミ箱に1件の投稿があります"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(tt.lang, nil).N(tt.n, "%d post in the trash", "%d posts in the trash")
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestMonth(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"en", "March 2024"},
+		{"de", "März 2024"},
+		{"ja", "2024年3月"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			assert.Equal(t, New(tt.lang, nil).Month(2024, time.March), tt.want)
+		})
+	}
+}
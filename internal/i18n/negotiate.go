@@ -0,0 +1,50 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Negotiate picks the supported language the client prefers most from the
+// value of an Accept-Language header, falling back to DefaultLanguage.
+// Regional variants match their base language, so "de-AT" selects German.
+func Negotiate(acceptLanguage string) string {
+	type pref struct {
+		tag string
+		q   float64
+	}
+
+	var prefs []pref
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if q > 0 {
+			prefs = append(prefs, pref{strings.ToLower(tag), q})
+		}
+	}
+
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	for _, p := range prefs {
+		base, _, _ := strings.Cut(p.tag, "-")
+		if Supported(base) {
+			return base
+		}
+	}
+
+	return DefaultLanguage
+}
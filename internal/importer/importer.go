@@ -0,0 +1,49 @@
+// Package importer reads posts exported from other blogging platforms.
+package importer
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Post is a post read from an export, before its author has been matched
+// to a user of this blog.
+type Post struct {
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"author_email,omitempty"`
+	Slug        string    `json:"slug,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Created     time.Time `json:"created"`
+}
+
+var slugRX = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns s into a lowercase, dash-separated slug.
+func Slugify(s string) string {
+	return strings.Trim(slugRX.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// normalize tidies up the fields of posts read by the various readers.
+func normalize(p *Post) {
+	p.Title = strings.TrimSpace(p.Title)
+	p.Author = strings.TrimSpace(p.Author)
+	p.AuthorEmail = strings.TrimSpace(p.AuthorEmail)
+	p.Slug = Slugify(p.Slug)
+	p.Created = p.Created.UTC()
+
+	seen := map[string]bool{}
+	tags := []string{}
+
+	for _, tag := range p.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	p.Tags = tags
+}
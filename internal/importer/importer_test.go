@@ -0,0 +1,78 @@
+package importer
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestReadWXR(t *testing.T) {
+	f, err := os.Open("./testdata/export.wxr")
+	assert.NilError(t, err)
+	defer f.Close()
+
+	posts, skipped, err := ReadWXR(f)
+	assert.NilError(t, err)
+	assert.Equal(t, skipped, 2)
+	assert.Equal(t, len(posts), 1)
+
+	post := posts[0]
+	assert.Equal(t, post.Title, "Hello World")
+	assert.Equal(t, post.Content, "<p>Welcome to my blog.</p>")
+	assert.Equal(t, post.Author, "alice")
+	assert.Equal(t, post.AuthorEmail, "alice@example.com")
+	assert.Equal(t, post.Slug, "hello-world")
+	assert.Equal(t, strings.Join(post.Tags, ","), "intro,meta")
+	assert.Equal(t, post.Created, time.Date(2015, 6, 1, 9, 30, 0, 0, time.UTC))
+}
+
+func TestReadMarkdown(t *testing.T) {
+	posts, skipped, err := ReadMarkdown(os.DirFS("./testdata/markdown"))
+	assert.NilError(t, err)
+	assert.Equal(t, skipped, 1)
+	assert.Equal(t, len(posts), 1)
+
+	post := posts[0]
+	assert.Equal(t, post.Title, "First Steps")
+	assert.Equal(t, post.Content, "Some *markdown* content.")
+	assert.Equal(t, post.Author, "bob")
+	assert.Equal(t, post.Slug, "first-steps")
+	assert.Equal(t, strings.Join(post.Tags, ","), "go,tutorial")
+	assert.Equal(t, post.Created, time.Date(2019, 2, 3, 3, 5, 6, 0, time.UTC))
+}
+
+func TestParseMarkdownErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"No front matter", "Just text"},
+		{"Unterminated", "---\ntitle: x\n"},
+		{"No date", "---\ntitle: x\n---\nbody"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseMarkdown([]byte(tt.input))
+			assert.Equal(t, err != nil, true)
+		})
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	f, err := os.Open("./testdata/export.json")
+	assert.NilError(t, err)
+	defer f.Close()
+
+	posts, err := ReadJSON(f)
+	assert.NilError(t, err)
+	assert.Equal(t, len(posts), 1)
+	assert.Equal(t, posts[0].Slug, "from-json")
+	assert.Equal(t, posts[0].Created, time.Date(2021, 7, 8, 10, 0, 0, 0, time.UTC))
+
+	_, err = ReadJSON(strings.NewReader(`{"version": 99}`))
+	assert.Equal(t, err != nil, true)
+}
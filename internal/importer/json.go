@@ -0,0 +1,37 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONVersion is the version of the JSON export format understood by
+// ReadJSON.
+const JSONVersion = 1
+
+// JSONExport is the blog's own JSON export format.
+type JSONExport struct {
+	Version int     `json:"version"`
+	Posts   []*Post `json:"posts"`
+}
+
+// ReadJSON reads the posts from the blog's own JSON export format.
+func ReadJSON(r io.Reader) ([]*Post, error) {
+	var export JSONExport
+
+	err := json.NewDecoder(r).Decode(&export)
+	if err != nil {
+		return nil, fmt.Errorf("importer: reading json: %w", err)
+	}
+
+	if export.Version != JSONVersion {
+		return nil, fmt.Errorf("importer: unsupported json export version %d", export.Version)
+	}
+
+	for _, post := range export.Posts {
+		normalize(post)
+	}
+
+	return export.Posts, nil
+}
@@ -0,0 +1,106 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type frontMatter struct {
+	Title  string    `yaml:"title"`
+	Author string    `yaml:"author"`
+	Email  string    `yaml:"email"`
+	Slug   string    `yaml:"slug"`
+	Tags   []string  `yaml:"tags"`
+	Date   time.Time `yaml:"date"`
+	Draft  bool      `yaml:"draft"`
+}
+
+// ReadMarkdown reads every .md file in fsys as a post with YAML front
+// matter delimited by "---" lines. The slug defaults to the file name and
+// files marked as drafts are skipped; the number of skipped files is
+// returned alongside the posts.
+func ReadMarkdown(fsys fs.FS) ([]*Post, int, error) {
+	posts := []*Post{}
+	skipped := 0
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".md" {
+			return err
+		}
+
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		post, draft, err := parseMarkdown(b)
+		if err != nil {
+			return fmt.Errorf("importer: %s: %w", name, err)
+		}
+
+		if draft {
+			skipped++
+			return nil
+		}
+
+		if post.Slug == "" {
+			post.Slug = strings.TrimSuffix(path.Base(name), ".md")
+		}
+
+		normalize(post)
+		posts = append(posts, post)
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, skipped, nil
+}
+
+func parseMarkdown(b []byte) (*Post, bool, error) {
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+
+	rest, ok := bytes.CutPrefix(b, []byte("---\n"))
+	if !ok {
+		return nil, false, fmt.Errorf("missing front matter")
+	}
+
+	header, body, ok := bytes.Cut(rest, []byte("\n---\n"))
+	if !ok {
+		header, ok = bytes.CutSuffix(rest, []byte("\n---"))
+		if !ok {
+			return nil, false, fmt.Errorf("unterminated front matter")
+		}
+	}
+
+	var fm frontMatter
+
+	err := yaml.Unmarshal(header, &fm)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if fm.Date.IsZero() {
+		return nil, false, fmt.Errorf("front matter has no date")
+	}
+
+	post := &Post{
+		Title:       fm.Title,
+		Content:     strings.TrimSpace(string(body)),
+		Author:      fm.Author,
+		AuthorEmail: fm.Email,
+		Slug:        fm.Slug,
+		Tags:        fm.Tags,
+		Created:     fm.Date,
+	}
+
+	return post, fm.Draft, nil
+}
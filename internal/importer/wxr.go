@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type wxrFile struct {
+	Authors []wxrAuthor `xml:"channel>author"`
+	Items   []wxrItem   `xml:"channel>item"`
+}
+
+type wxrAuthor struct {
+	Login string `xml:"author_login"`
+	Email string `xml:"author_email"`
+}
+
+type wxrItem struct {
+	Title      string        `xml:"title"`
+	Creator    string        `xml:"creator"`
+	Content    string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostName   string        `xml:"post_name"`
+	PostDate   string        `xml:"post_date_gmt"`
+	Status     string        `xml:"status"`
+	PostType   string        `xml:"post_type"`
+	Categories []wxrCategory `xml:"category"`
+}
+
+type wxrCategory struct {
+	Domain string `xml:"domain,attr"`
+	Name   string `xml:",chardata"`
+}
+
+// ReadWXR reads the published posts from a WordPress eXtended RSS export.
+// Pages, attachments, drafts and other items are skipped; the number of
+// skipped items is returned alongside the posts.
+func ReadWXR(r io.Reader) ([]*Post, int, error) {
+	var f wxrFile
+
+	err := xml.NewDecoder(r).Decode(&f)
+	if err != nil {
+		return nil, 0, fmt.Errorf("importer: reading wxr: %w", err)
+	}
+
+	emails := map[string]string{}
+	for _, a := range f.Authors {
+		emails[a.Login] = a.Email
+	}
+
+	posts := []*Post{}
+	skipped := 0
+
+	for _, item := range f.Items {
+		if item.PostType != "post" || item.Status != "publish" {
+			skipped++
+			continue
+		}
+
+		created, err := time.Parse(time.DateTime, item.PostDate)
+		if err != nil {
+			return nil, 0, fmt.Errorf("importer: post %q: invalid post_date_gmt: %w", item.Title, err)
+		}
+
+		post := &Post{
+			Title:       item.Title,
+			Content:     item.Content,
+			Author:      item.Creator,
+			AuthorEmail: emails[item.Creator],
+			Slug:        item.PostName,
+			Created:     created,
+		}
+
+		for _, c := range item.Categories {
+			if c.Domain == "post_tag" {
+				post.Tags = append(post.Tags, c.Name)
+			}
+		}
+
+		normalize(post)
+		posts = append(posts, post)
+	}
+
+	return posts, skipped, nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// ArchiveMonth is a month with published posts: how many there are and
+// when any of them was last changed.
+type ArchiveMonth struct {
+	Year    int
+	Month   time.Month
+	Posts   int
+	Updated time.Time
+}
+
+// Archive counts the published, public posts per month of their creation
+// in UTC, newest month first.
+func (m *PostModel) Archive() ([]*ArchiveMonth, error) {
+	stmt := `SELECT YEAR(created), MONTH(created), COUNT(*), MAX(updated) FROM posts
+	WHERE deleted IS NULL AND status = 'published' AND visibility = 'public'
+	GROUP BY YEAR(created), MONTH(created)
+	ORDER BY YEAR(created) DESC, MONTH(created) DESC`
+
+	rows, err := m.DB.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	months := []*ArchiveMonth{}
+
+	for rows.Next() {
+		month := &ArchiveMonth{}
+
+		err = rows.Scan(&month.Year, &month.Month, &month.Posts, &month.Updated)
+		if err != nil {
+			return nil, err
+		}
+
+		months = append(months, month)
+	}
+
+	return months, rows.Err()
+}
+
+// GetBetween returns the published, public posts created from from up to
+// but not including to, newest first, without their content like Latest.
+func (m *PostModel) GetBetween(from, to time.Time) ([]*Post, error) {
+	return m.listCards(`p.deleted IS NULL AND p.status = 'published' AND p.visibility = 'public'
+	AND p.created >= ? AND p.created < ?`, "p.created DESC, p.id DESC", from.UTC(), to.UTC())
+}
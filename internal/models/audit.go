@@ -0,0 +1,158 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Audit actions. Failed logins have no actor; the attempted username is
+// recorded in After.
+const (
+	AuditLogin          = "login"
+	AuditLoginFailed    = "login.failed"
+	AuditLogout         = "logout"
+	AuditRegister       = "register"
+	AuditPasswordChange = "password.change"
+	AuditPostCreate     = "post.create"
+	AuditPostUpdate     = "post.update"
+	AuditPostDelete     = "post.delete"
+	AuditPostRestore    = "post.restore"
+	AuditPostPurge      = "post.purge"
+	AuditPostReview     = "post.review"
+	AuditSeriesCreate   = "series.create"
+	AuditPostApprove    = "moderation.approve"
+	AuditPostReject     = "moderation.reject"
+	AuditUserDisable    = "admin.user.disable"
+	AuditUserEnable     = "admin.user.enable"
+	AuditUserRole       = "admin.user.role"
+	AuditPostsBulk      = "admin.posts.bulk"
+	AuditBackup         = "admin.backup"
+)
+
+// AuditActions returns every audit action, in the order they are offered
+// as filters.
+func AuditActions() []string {
+	return []string{
+		AuditLogin, AuditLoginFailed, AuditLogout, AuditRegister, AuditPasswordChange,
+		AuditPostCreate, AuditPostUpdate, AuditPostDelete, AuditPostRestore, AuditPostPurge, AuditPostReview,
+		AuditSeriesCreate,
+		AuditPostApprove, AuditPostReject,
+		AuditUserDisable, AuditUserEnable, AuditUserRole, AuditPostsBulk, AuditBackup,
+	}
+}
+
+type AuditModelInterface interface {
+	Insert(event *AuditEvent) error
+	List(filter AuditFilter) ([]*AuditEvent, error)
+}
+
+// AuditEvent is an entry in the audit log. Before and After hold JSON
+// describing the target before and after the change, where that applies.
+type AuditEvent struct {
+	Id         int             `json:"id"`
+	Time       time.Time       `json:"time"`
+	Action     string          `json:"action"`
+	ActorId    int             `json:"actor_id,omitempty"`
+	Actor      string          `json:"actor,omitempty"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	TargetType string          `json:"target_type,omitempty"`
+	TargetId   int             `json:"target_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditFilter selects audit events. Zero fields match everything; Until is
+// exclusive.
+type AuditFilter struct {
+	Action string
+	Actor  string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// AuditModel only ever inserts into audit_log, so the database user can be
+// denied UPDATE and DELETE on the table.
+type AuditModel struct {
+	DB *sql.DB
+}
+
+func (m *AuditModel) Insert(event *AuditEvent) error {
+	stmt := `INSERT INTO audit_log (created, action, actor_id, ip, user_agent, target_type, target_id, before_data, after_data)
+	VALUES(UTC_TIMESTAMP(6), ?, NULLIF(?, 0), ?, ?, NULLIF(?, ''), NULLIF(?, 0), ?, ?)`
+
+	_, err := m.DB.Exec(stmt, event.Action, event.ActorId, event.IP, event.UserAgent,
+		event.TargetType, event.TargetId, nullJSON(event.Before), nullJSON(event.After))
+	return err
+}
+
+func nullJSON(data json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(data), Valid: len(data) > 0}
+}
+
+// List returns the events matching filter, newest first.
+func (m *AuditModel) List(filter AuditFilter) ([]*AuditEvent, error) {
+	stmt := `SELECT a.id, a.created, a.action, COALESCE(a.actor_id, 0), COALESCE(u.username, ''),
+	a.ip, a.user_agent, COALESCE(a.target_type, ''), COALESCE(a.target_id, 0),
+	COALESCE(a.before_data, ''), COALESCE(a.after_data, '')
+	FROM audit_log a LEFT JOIN users u ON u.id = a.actor_id
+	WHERE TRUE`
+
+	var args []any
+
+	if filter.Action != "" {
+		stmt += " AND a.action = ?"
+		args = append(args, filter.Action)
+	}
+	if filter.Actor != "" {
+		stmt += " AND u.username = ?"
+		args = append(args, filter.Actor)
+	}
+	if !filter.Since.IsZero() {
+		stmt += " AND a.created >= ?"
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		stmt += " AND a.created < ?"
+		args = append(args, filter.Until.UTC())
+	}
+
+	stmt += " ORDER BY a.id DESC"
+
+	if filter.Limit > 0 {
+		stmt += " LIMIT " + strconv.Itoa(filter.Limit)
+	}
+
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*AuditEvent{}
+
+	for rows.Next() {
+		event := &AuditEvent{}
+		var before, after string
+
+		err = rows.Scan(&event.Id, &event.Time, &event.Action, &event.ActorId, &event.Actor,
+			&event.IP, &event.UserAgent, &event.TargetType, &event.TargetId, &before, &after)
+		if err != nil {
+			return nil, err
+		}
+
+		if before != "" {
+			event.Before = json.RawMessage(before)
+		}
+		if after != "" {
+			event.After = json.RawMessage(after)
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
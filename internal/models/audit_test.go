@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestAuditModel(t *testing.T) {
+	db := newTestDB(t)
+
+	m := AuditModel{DB: db}
+
+	assert.NilError(t, m.Insert(&AuditEvent{Action: AuditLoginFailed, IP: "192.0.2.1", After: json.RawMessage(`{"username":"bobby"}`)}))
+	assert.NilError(t, m.Insert(&AuditEvent{Action: AuditLogin, ActorId: 1, IP: "192.0.2.1", UserAgent: "test"}))
+	assert.NilError(t, m.Insert(&AuditEvent{
+		Action:     AuditPostUpdate,
+		ActorId:    1,
+		TargetType: "post",
+		TargetId:   1,
+		Before:     json.RawMessage(`{"title":"old"}`),
+		After:      json.RawMessage(`{"title":"new"}`),
+	}))
+
+	events, err := m.List(AuditFilter{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 3)
+	assert.Equal(t, events[0].Action, AuditPostUpdate)
+	assert.Equal(t, events[0].Actor, "alice")
+	assert.Equal(t, string(events[0].Before), `{"title": "old"}`)
+	assert.Equal(t, events[2].ActorId, 0)
+
+	events, err = m.List(AuditFilter{Actor: "alice", Limit: 1})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+
+	events, err = m.List(AuditFilter{Action: AuditLoginFailed})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].IP, "192.0.2.1")
+
+	events, err = m.List(AuditFilter{Until: time.Now().Add(-time.Hour)})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+}
@@ -3,8 +3,18 @@ package models
 import "errors"
 
 var (
-	ErrNoRecord           = errors.New("models: no matching record found")
-	ErrInvalidCredentials = errors.New("models: invalid credentials")
-	ErrDuplicateUsername  = errors.New("models: duplicate username")
-	ErrDuplicateEmail     = errors.New("models: duplicate email")
+	ErrNoRecord            = errors.New("models: no matching record found")
+	ErrInvalidCredentials  = errors.New("models: invalid credentials")
+	ErrDuplicateUsername   = errors.New("models: duplicate username")
+	ErrDuplicateEmail      = errors.New("models: duplicate email")
+	ErrDuplicateSlug       = errors.New("models: duplicate slug")
+	ErrAccountDisabled     = errors.New("models: account disabled")
+	ErrInvalidRole         = errors.New("models: invalid role")
+	ErrInvalidStatus       = errors.New("models: invalid status")
+	ErrInvalidVisibility   = errors.New("models: invalid visibility")
+	ErrInvalidTransition   = errors.New("models: invalid status transition")
+	ErrInvalidReaction     = errors.New("models: invalid reaction")
+	ErrInvalidSort         = errors.New("models: invalid sort order")
+	ErrSelfFollow          = errors.New("models: users can't follow themselves")
+	ErrInvalidNotification = errors.New("models: invalid notification kind")
 )
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// FollowCounts is how many users follow a user and how many users they
+// follow.
+type FollowCounts struct {
+	Followers int
+	Following int
+}
+
+// Follow is one user following another.
+type Follow struct {
+	FollowerId int
+	FollowedId int
+	Created    time.Time
+}
+
+// Follow makes followerId follow followedId. Following someone twice is
+// the same as following them once. It returns ErrSelfFollow if both are
+// the same user and ErrNoRecord if either doesn't exist.
+func (m *UserModel) Follow(followerId, followedId int) error {
+	if followerId == followedId {
+		return ErrSelfFollow
+	}
+
+	stmt := `INSERT INTO follows (follower_id, followed_id, created)
+	VALUES(?, ?, UTC_TIMESTAMP()) ON DUPLICATE KEY UPDATE follower_id = follower_id`
+
+	_, err := m.DB.Exec(stmt, followerId, followedId)
+	if err != nil {
+		var mySQLError *mysql.MySQLError
+		if errors.As(err, &mySQLError) && mySQLError.Number == 1452 {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Unfollow makes followerId stop following followedId, if they did.
+func (m *UserModel) Unfollow(followerId, followedId int) error {
+	_, err := m.DB.Exec("DELETE FROM follows WHERE follower_id = ? AND followed_id = ?", followerId, followedId)
+
+	return err
+}
+
+func (m *UserModel) IsFollowing(followerId, followedId int) (bool, error) {
+	var following bool
+
+	stmt := "SELECT EXISTS(SELECT true FROM follows WHERE follower_id = ? AND followed_id = ?)"
+
+	err := m.DB.QueryRow(stmt, followerId, followedId).Scan(&following)
+	return following, err
+}
+
+func (m *UserModel) FollowCounts(userId int) (*FollowCounts, error) {
+	stmt := `SELECT
+	(SELECT COUNT(*) FROM follows WHERE followed_id = ?),
+	(SELECT COUNT(*) FROM follows WHERE follower_id = ?)`
+
+	counts := &FollowCounts{}
+
+	err := m.DB.QueryRow(stmt, userId, userId).Scan(&counts.Followers, &counts.Following)
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// Followers returns the users following userId.
+func (m *UserModel) Followers(userId int) ([]*User, error) {
+	return m.list("id IN (SELECT follower_id FROM follows WHERE followed_id = ?)", 0, userId)
+}
+
+// Following returns the users userId follows.
+func (m *UserModel) Following(userId int) ([]*User, error) {
+	return m.list("id IN (SELECT followed_id FROM follows WHERE follower_id = ?)", 0, userId)
+}
+
+// AllFollows returns who follows whom, for backups.
+func (m *UserModel) AllFollows() ([]*Follow, error) {
+	stmt := `SELECT follower_id, followed_id, created FROM follows ORDER BY follower_id, followed_id`
+
+	rows, err := m.DB.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	follows := []*Follow{}
+
+	for rows.Next() {
+		follow := &Follow{}
+
+		err = rows.Scan(&follow.FollowerId, &follow.FollowedId, &follow.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		follows = append(follows, follow)
+	}
+
+	return follows, rows.Err()
+}
+
+// UpsertFollow adds a follow, or updates when it started if it already
+// exists. It is used to restore backups.
+func (m *UserModel) UpsertFollow(follow *Follow) error {
+	if follow.FollowerId == follow.FollowedId {
+		return ErrSelfFollow
+	}
+
+	stmt := `INSERT INTO follows (follower_id, followed_id, created) VALUES(?, ?, ?) AS new
+	ON DUPLICATE KEY UPDATE created = new.created`
+
+	_, err := m.DB.Exec(stmt, follow.FollowerId, follow.FollowedId, follow.Created.UTC())
+	if err != nil {
+		var mySQLError *mysql.MySQLError
+		if errors.As(err, &mySQLError) && mySQLError.Number == 1452 {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestUserModelFollow(t *testing.T) {
+	db := newTestDB(t)
+
+	users := UserModel{DB: db, BcryptCost: 4}
+	posts := PostModel{DB: db}
+
+	assert.NilError(t, users.Insert("dave", "dave@example.com", "pa$$word"))
+	dave, err := users.GetByUsername("dave")
+	assert.NilError(t, err)
+
+	assert.NilError(t, users.Follow(dave.Id, 1))
+	assert.NilError(t, users.Follow(dave.Id, 1))
+	assert.Equal(t, users.Follow(dave.Id, dave.Id), ErrSelfFollow)
+	assert.Equal(t, users.Follow(dave.Id, 99), ErrNoRecord)
+
+	following, err := users.IsFollowing(dave.Id, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, following, true)
+
+	counts, err := users.FollowCounts(1)
+	assert.NilError(t, err)
+	assert.Equal(t, *counts, FollowCounts{Followers: 1})
+
+	followers, err := users.Followers(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(followers), 1)
+	assert.Equal(t, followers[0].Username, "dave")
+
+	followed, err := users.Following(dave.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, followed[0].Username, "alice")
+
+	for _, title := range []string{"First", "Second", "Third"} {
+		newPost(t, &posts, 1, title, "By alice", PostStatusPublished, PostVisibilityPublic)
+	}
+	newPost(t, &posts, dave.Id, "Own post", "By dave", PostStatusPublished, PostVisibilityPublic)
+
+	page, err := posts.Timeline(dave.Id, 0, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(page), 2)
+	assert.Equal(t, page[0].Title, "Third")
+	assert.Equal(t, page[1].Title, "Second")
+
+	page, err = posts.Timeline(dave.Id, page[1].Id, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(page), 2)
+	assert.Equal(t, page[0].Title, "First")
+	assert.Equal(t, page[1].Title, "An old silent pond")
+
+	assert.NilError(t, users.Unfollow(dave.Id, 1))
+
+	page, err = posts.Timeline(dave.Id, 0, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(page), 0)
+
+	own, err := posts.LatestByAuthor(dave.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(own), 1)
+	assert.Equal(t, own[0].Author, "dave")
+}
+
+func TestUserModelUpsertFollow(t *testing.T) {
+	db := newTestDB(t)
+
+	users := UserModel{DB: db, BcryptCost: 4}
+
+	assert.NilError(t, users.Insert("dave", "dave@example.com", "pa$$word"))
+	dave, err := users.GetByUsername("dave")
+	assert.NilError(t, err)
+
+	created := time.Date(2024, 3, 18, 9, 0, 0, 0, time.UTC)
+
+	for range 2 {
+		assert.NilError(t, users.UpsertFollow(&Follow{FollowerId: 1, FollowedId: dave.Id, Created: created}))
+	}
+	assert.Equal(t, users.UpsertFollow(&Follow{FollowerId: 1, FollowedId: 99}), ErrNoRecord)
+	assert.Equal(t, users.UpsertFollow(&Follow{FollowerId: 1, FollowedId: 1}), ErrSelfFollow)
+
+	follows, err := users.AllFollows()
+	assert.NilError(t, err)
+	assert.Equal(t, len(follows), 1)
+	assert.Equal(t, *follows[0], Follow{FollowerId: 1, FollowedId: dave.Id, Created: created})
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSummary is how many characters of a post's first paragraph are kept
+// as its summary.
+const maxSummary = 200
+
+var paragraphBreakRX = regexp.MustCompile(`\n[ \t\r]*\n`)
+
+// Summarize returns the summary of a post's content: its first paragraph,
+// shortened with Excerpt if it is long.
+func Summarize(content string) string {
+	first := paragraphBreakRX.Split(strings.TrimSpace(content), 2)[0]
+	return Excerpt(first, maxSummary)
+}
+
+// CountWords returns the number of words in a post's content, counting
+// runs of characters between white space.
+func CountWords(content string) int {
+	return len(strings.Fields(content))
+}
+
+// Excerpt returns s with runs of white space collapsed, shortened to at
+// most n characters at a word boundary if it is longer.
+func Excerpt(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	cut := string([]rune(s)[:n])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		return cut[:i] + "…"
+	}
+
+	return cut + "…"
+}
+
+// Orders of post listings. Posts with as many reactions are listed newest
+// first.
+const (
+	SortNewest      = "newest"
+	SortMostReacted = "reactions"
+)
+
+func ListingSorts() []string {
+	return []string{SortNewest, SortMostReacted}
+}
+
+// Latest returns the published, public posts for listings in the given
+// order, newest first if sort is empty. Their content is left out; use
+// Teaser and ReadingTime instead.
+func (m *PostModel) Latest(sort string) ([]*Post, error) {
+	order := "p.id DESC"
+
+	switch sort {
+	case "", SortNewest:
+	case SortMostReacted:
+		order = "p.reaction_count DESC, p.id DESC"
+	default:
+		return nil, ErrInvalidSort
+	}
+
+	return m.listCards("p.deleted IS NULL AND p.status = 'published' AND p.visibility = 'public'", order)
+}
+
+// LatestByAuthor returns the published, public posts of a user for
+// listings, newest first.
+func (m *PostModel) LatestByAuthor(userId int) ([]*Post, error) {
+	return m.listCards(`p.deleted IS NULL AND p.status = 'published' AND p.visibility = 'public'
+	AND p.user_id = ?`, "p.id DESC", userId)
+}
+
+// Timeline returns up to limit published, public posts by the users
+// userId follows, newest first. Pages are fetched by passing the ID of the
+// last post of the previous page as before, or 0 for the first page, so
+// posts published in the meantime don't shift them.
+func (m *PostModel) Timeline(userId, before, limit int) ([]*Post, error) {
+	cond := `p.deleted IS NULL AND p.status = 'published' AND p.visibility = 'public'
+	AND p.user_id IN (SELECT followed_id FROM follows WHERE follower_id = ?)`
+	args := []any{userId}
+
+	if before > 0 {
+		cond += " AND p.id < ?"
+		args = append(args, before)
+	}
+
+	return m.listCards(cond, "p.id DESC LIMIT "+strconv.Itoa(limit), args...)
+}
+
+// listCards returns the posts matching cond in the given order, with what
+// listings show of them but not their content or tags.
+func (m *PostModel) listCards(cond, order string, args ...any) ([]*Post, error) {
+	stmt := `SELECT p.id, COALESCE(p.user_id, 0), COALESCE(u.username, ''), p.title, p.status, p.visibility,
+	p.excerpt, p.cover_image, p.summary, p.word_count, p.reaction_count, p.created, p.updated
+	FROM posts p LEFT JOIN users u ON u.id = p.user_id
+	WHERE ` + cond + ` ORDER BY ` + order
+
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []*Post{}
+
+	for rows.Next() {
+		post := &Post{}
+
+		err = rows.Scan(&post.Id, &post.UserId, &post.Author, &post.Title, &post.Status, &post.Visibility,
+			&post.Excerpt, &post.CoverImage, &post.Summary, &post.WordCount, &post.Reactions, &post.Created, &post.Updated)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
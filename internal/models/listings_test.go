@@ -0,0 +1,98 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"Short", "Hello world", 20, "Hello world"},
+		{"White space", "  Hello\n\n world ", 20, "Hello world"},
+		{"Word boundary", "Hello wonderful world", 12, "Hello…"},
+		{"Long word", "Supercalifragilistic", 5, "Super…"},
+		{"Multibyte", "日本語の文章です", 3, "日本語…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Excerpt(tt.s, tt.n), tt.want)
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"One paragraph", "A frog jumps\ninto the pond", "A frog jumps into the pond"},
+		{"First paragraph", "\n\nA frog jumps.\n  \nThe sound of water.", "A frog jumps."},
+		{"Windows line endings", "A frog jumps.\r\n\r\nThe sound of water.", "A frog jumps."},
+		{"Empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Summarize(tt.content), tt.want)
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	tests := []struct {
+		words int
+		want  int
+	}{
+		{0, 1},
+		{1, 1},
+		{200, 1},
+		{201, 2},
+		{1000, 5},
+	}
+
+	for _, tt := range tests {
+		post := &Post{WordCount: tt.words}
+		assert.Equal(t, post.ReadingTime(), tt.want)
+	}
+}
+
+func TestPostModelLatest(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	haiku := &Post{UserId: 1, Title: "Haiku", Content: "The sound of water\n\nOne two three four five six",
+		Status: PostStatusPublished, Visibility: PostVisibilityPublic, CoverImage: "https://example.com/frog.png"}
+	_, err := m.Save(haiku, nil, 1, "", "")
+	assert.NilError(t, err)
+
+	newPost(t, &m, 1, "Draft", "Not yet", PostStatusDraft, PostVisibilityPublic)
+
+	posts, err := m.Latest(SortNewest)
+	assert.NilError(t, err)
+	assert.Equal(t, len(posts), 2)
+	assert.Equal(t, posts[0].Title, "Haiku")
+	assert.Equal(t, posts[0].Author, "alice")
+	assert.Equal(t, posts[0].Content, "")
+	assert.Equal(t, posts[0].Teaser(), "The sound of water")
+	assert.Equal(t, posts[0].WordCount, 10)
+	assert.Equal(t, posts[0].CoverImage, "https://example.com/frog.png")
+
+	haiku.Content, haiku.Excerpt, haiku.CoverImage = "Plop", "Water and frogs", ""
+	_, err = m.Save(haiku, nil, 1, "", "")
+	assert.NilError(t, err)
+
+	post, err := m.Get(haiku.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Teaser(), "Water and frogs")
+	assert.Equal(t, post.Summary, "Plop")
+	assert.Equal(t, post.WordCount, 1)
+}
@@ -0,0 +1,55 @@
+package mocks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// AuditModel is an in-memory implementation of
+// models.AuditModelInterface. Unlike the real model it filters on the
+// Actor stored with each event rather than joining the users.
+type AuditModel struct {
+	mu     sync.Mutex
+	events []*models.AuditEvent
+}
+
+func (m *AuditModel) Insert(event *models.AuditEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := *event
+	e.Id = len(m.events) + 1
+	e.Time = time.Now().UTC()
+	m.events = append(m.events, &e)
+
+	return nil
+}
+
+func (m *AuditModel) List(filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := []*models.AuditEvent{}
+
+	for i := len(m.events) - 1; i >= 0; i-- {
+		e := m.events[i]
+
+		switch {
+		case filter.Action != "" && e.Action != filter.Action:
+		case filter.Actor != "" && e.Actor != filter.Actor:
+		case !filter.Since.IsZero() && e.Time.Before(filter.Since):
+		case !filter.Until.IsZero() && !e.Time.Before(filter.Until):
+		default:
+			event := *e
+			events = append(events, &event)
+		}
+
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,186 @@
+package mocks
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// NotificationModel is an in-memory implementation of
+// models.NotificationModelInterface. The zero value is an empty model
+// ready to use; List fills in Actor and PostTitle only if Users and Posts
+// are set.
+type NotificationModel struct {
+	Users *UserModel
+	Posts *PostModel
+
+	mu            sync.Mutex
+	notifications []*models.Notification
+	muted         map[int][]string
+}
+
+func (m *NotificationModel) Insert(n *models.Notification) error {
+	if !models.ValidNotificationKind(n.Kind) {
+		return models.ErrInvalidNotification
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if slices.Contains(m.muted[n.UserId], n.Kind) {
+		return nil
+	}
+
+	id := 1
+	if len(m.notifications) > 0 {
+		id = m.notifications[len(m.notifications)-1].Id + 1
+	}
+
+	notification := *n
+	notification.Id = id
+	notification.Actor = ""
+	notification.PostTitle = ""
+	notification.Created = time.Now().UTC().Truncate(time.Second)
+	notification.Read = false
+	m.notifications = append(m.notifications, &notification)
+
+	return nil
+}
+
+func (m *NotificationModel) List(userId, limit int) ([]*models.Notification, error) {
+	m.mu.Lock()
+	notifications := []*models.Notification{}
+	for i := len(m.notifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+		if m.notifications[i].UserId == userId {
+			n := *m.notifications[i]
+			notifications = append(notifications, &n)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, n := range notifications {
+		if m.Users != nil && n.ActorId != 0 {
+			if actor, err := m.Users.Get(n.ActorId); err == nil {
+				n.Actor = actor.Username
+			}
+		}
+		if m.Posts != nil && n.PostId != 0 {
+			if post, err := m.Posts.Get(n.PostId); err == nil {
+				n.PostTitle = post.Title
+			}
+		}
+	}
+
+	return notifications, nil
+}
+
+func (m *NotificationModel) Unread(userId int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, n := range m.notifications {
+		if n.UserId == userId && !n.Read {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (m *NotificationModel) MarkRead(userId, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, n := range m.notifications {
+		if n.UserId == userId && (id == 0 || n.Id == id) {
+			n.Read = true
+		}
+	}
+
+	return nil
+}
+
+func (m *NotificationModel) GetAll() ([]*models.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	notifications := []*models.Notification{}
+	for _, n := range m.notifications {
+		notification := *n
+		notifications = append(notifications, &notification)
+	}
+
+	return notifications, nil
+}
+
+func (m *NotificationModel) Upsert(n *models.Notification) error {
+	if !models.ValidNotificationKind(n.Kind) {
+		return models.ErrInvalidNotification
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	notification := *n
+	notification.Actor = ""
+	notification.PostTitle = ""
+	notification.Created = notification.Created.UTC()
+
+	for i, existing := range m.notifications {
+		if existing.Id == notification.Id {
+			m.notifications[i] = &notification
+			return nil
+		}
+	}
+
+	m.notifications = append(m.notifications, &notification)
+	slices.SortFunc(m.notifications, func(a, b *models.Notification) int { return a.Id - b.Id })
+
+	return nil
+}
+
+func (m *NotificationModel) Muted(userId int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kinds := append([]string{}, m.muted[userId]...)
+	slices.Sort(kinds)
+
+	return kinds, nil
+}
+
+func (m *NotificationModel) AllMuted() (map[int][]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	muted := map[int][]string{}
+	for userId, kinds := range m.muted {
+		if len(kinds) > 0 {
+			muted[userId] = slices.Clone(kinds)
+			slices.Sort(muted[userId])
+		}
+	}
+
+	return muted, nil
+}
+
+func (m *NotificationModel) SetMuted(userId int, kinds []string) error {
+	for _, kind := range kinds {
+		if !models.ValidNotificationKind(kind) {
+			return models.ErrInvalidNotification
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.muted == nil {
+		m.muted = map[int][]string{}
+	}
+	m.muted[userId] = slices.Clone(kinds)
+
+	return nil
+}
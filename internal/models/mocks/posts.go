@@ -0,0 +1,853 @@
+package mocks
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PostModel is an in-memory implementation of models.PostModelInterface.
+// The zero value is an empty model ready to use; Timeline, SetReferences
+// and ClaimMentions also need Users to know who follows whom and who
+// exists, and Save needs Series to start new series.
+type PostModel struct {
+	Users  *UserModel
+	Series *SeriesModel
+
+	mu        sync.Mutex
+	posts     map[int]*models.Post
+	reviews   []*models.Review
+	reactions map[reaction]time.Time
+	hashtags  map[int][]string
+	notified  map[mention]bool
+	nextId    int
+}
+
+type mention struct {
+	postId int
+	user   string
+}
+
+type reaction struct {
+	postId, userId int
+	kind           string
+}
+
+func (m *PostModel) init() {
+	if m.posts == nil {
+		m.posts = map[int]*models.Post{}
+	}
+}
+
+func (m *PostModel) Import(post *models.Post) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	if post.Slug != "" {
+		for _, p := range m.posts {
+			if p.Slug == post.Slug {
+				return 0, models.ErrDuplicateSlug
+			}
+		}
+	}
+
+	p := *post
+	m.nextId++
+	p.Id = m.nextId
+	p.Created = p.Created.UTC()
+	setDefaults(&p)
+	m.posts[p.Id] = &p
+
+	return p.Id, nil
+}
+
+func (m *PostModel) Get(id int) (*models.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, ok := m.posts[id]
+	if !ok || !post.Deleted.IsZero() {
+		return nil, models.ErrNoRecord
+	}
+
+	p := *post
+	return &p, nil
+}
+
+func (m *PostModel) GetBySlug(slug string) (*models.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, post := range m.posts {
+		if slug != "" && post.Slug == slug && post.Deleted.IsZero() {
+			p := *post
+			return &p, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (m *PostModel) GetAll() ([]*models.Post, error) {
+	return m.list(func(p *models.Post) bool {
+		return p.Deleted.IsZero() && p.Status == models.PostStatusPublished && p.Visibility == models.PostVisibilityPublic
+	}), nil
+}
+
+// Latest is like GetAll but leaves out the content, like the real model.
+func (m *PostModel) Latest(order string) ([]*models.Post, error) {
+	posts, _ := m.GetAll()
+
+	switch order {
+	case "", models.SortNewest:
+	case models.SortMostReacted:
+		sort.SliceStable(posts, func(i, j int) bool { return posts[i].Reactions > posts[j].Reactions })
+	default:
+		return nil, models.ErrInvalidSort
+	}
+
+	return withoutContent(posts), nil
+}
+
+func (m *PostModel) LatestByAuthor(userId int) ([]*models.Post, error) {
+	posts, _ := m.GetAll()
+
+	return withoutContent(slices.DeleteFunc(posts, func(p *models.Post) bool { return p.UserId != userId })), nil
+}
+
+func (m *PostModel) Timeline(userId, before, limit int) ([]*models.Post, error) {
+	followed := m.Users.FollowedIds(userId)
+
+	posts, _ := m.GetAll()
+	posts = slices.DeleteFunc(posts, func(p *models.Post) bool {
+		return !slices.Contains(followed, p.UserId) || before > 0 && p.Id >= before
+	})
+
+	return withoutContent(posts[:min(limit, len(posts))]), nil
+}
+
+func withoutContent(posts []*models.Post) []*models.Post {
+	for _, post := range posts {
+		post.Content = ""
+	}
+	return posts
+}
+
+func (m *PostModel) GetByUser(userId int) ([]*models.Post, error) {
+	return m.list(func(p *models.Post) bool { return p.UserId == userId && p.Deleted.IsZero() }), nil
+}
+
+func (m *PostModel) GetEverything() ([]*models.Post, error) {
+	return m.list(func(p *models.Post) bool { return true }), nil
+}
+
+func (m *PostModel) Upsert(post *models.Post) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	for _, p := range m.posts {
+		if post.Slug != "" && p.Slug == post.Slug && p.Id != post.Id {
+			return models.ErrDuplicateSlug
+		}
+	}
+
+	p := *post
+	p.Created = p.Created.UTC()
+	setDefaults(&p)
+	if existing, ok := m.posts[p.Id]; ok {
+		if len(p.PasswordHash) == 0 {
+			p.PasswordHash = existing.PasswordHash
+		}
+		p.Reactions = existing.Reactions
+	}
+	m.posts[p.Id] = &p
+
+	if p.Id > m.nextId {
+		m.nextId = p.Id
+	}
+
+	return nil
+}
+
+func setDefaults(p *models.Post) {
+	if p.Status == "" {
+		p.Status = models.PostStatusPublished
+	}
+	if p.Visibility == "" {
+		p.Visibility = models.PostVisibilityPublic
+	}
+	if p.Updated.IsZero() {
+		p.Updated = p.Created
+	}
+	p.Updated = p.Updated.UTC()
+	p.Summary = models.Summarize(p.Content)
+	p.WordCount = models.CountWords(p.Content)
+}
+
+func (m *PostModel) List(status string) ([]*models.Post, error) {
+	return m.list(func(p *models.Post) bool {
+		return p.Deleted.IsZero() && (status == "" || p.Status == status)
+	}), nil
+}
+
+func (m *PostModel) SetStatus(ids []int, status string) (int, error) {
+	if !models.ValidPostStatus(status) {
+		return 0, models.ErrInvalidStatus
+	}
+
+	return m.updateMany(ids, func(p *models.Post) { p.Status = status }), nil
+}
+
+func (m *PostModel) Reassign(ids []int, userId int) (int, error) {
+	return m.updateMany(ids, func(p *models.Post) { p.UserId = userId }), nil
+}
+
+func (m *PostModel) CountSince(userId int, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+
+	for _, post := range m.posts {
+		if post.UserId == userId && !post.Created.Before(since) {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (m *PostModel) updateMany(ids []int, update func(*models.Post)) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+
+	for _, id := range ids {
+		if post, ok := m.posts[id]; ok && post.Deleted.IsZero() {
+			update(post)
+			n++
+		}
+	}
+
+	return n
+}
+
+func (m *PostModel) Stats() (*models.PostStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := &models.PostStats{}
+	tags := map[string]bool{}
+
+	for _, post := range m.posts {
+		switch {
+		case !post.Deleted.IsZero():
+			stats.Trashed++
+		case post.Status == models.PostStatusPublished:
+			stats.Published++
+		case post.Status == models.PostStatusFlagged:
+			stats.Flagged++
+		case post.Status == models.PostStatusInReview:
+			stats.InReview++
+		default:
+			stats.Drafts++
+		}
+
+		for _, tag := range post.Tags {
+			tags[tag] = true
+		}
+	}
+
+	stats.Tags = len(tags)
+
+	return stats, nil
+}
+
+// Save is like the real model's, but not atomic: the post is saved even if
+// taking action on it fails.
+func (m *PostModel) Save(post *models.Post, series *models.Series, actorId int, password, action string) (*models.Review, error) {
+	if !models.ValidPostStatus(post.Status) {
+		return nil, models.ErrInvalidStatus
+	}
+	if !models.ValidPostVisibility(post.Visibility) {
+		return nil, models.ErrInvalidVisibility
+	}
+
+	var passwordHash []byte
+	if password != "" {
+		var err error
+		passwordHash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	m.init()
+
+	saved, ok := m.posts[post.Id]
+	if post.Id != 0 && (!ok || !saved.Deleted.IsZero()) {
+		m.mu.Unlock()
+		return nil, models.ErrNoRecord
+	}
+
+	if post.Id == 0 {
+		m.nextId++
+		saved = &models.Post{Id: m.nextId, UserId: post.UserId, Created: time.Now().UTC()}
+		m.posts[saved.Id] = saved
+		post.Id = saved.Id
+	}
+
+	if series != nil {
+		series.UserId = post.UserId
+		m.Series.insert(series)
+		post.SeriesId = series.Id
+	}
+
+	if post.SeriesId != saved.SeriesId || post.SeriesPart != 0 && post.SeriesPart != saved.SeriesPart {
+		m.setSeries(saved, post.SeriesId, post.SeriesPart)
+	}
+
+	saved.Title = post.Title
+	saved.Content = post.Content
+	saved.Status = post.Status
+	saved.Visibility = post.Visibility
+	if passwordHash != nil {
+		saved.PasswordHash = passwordHash
+	}
+	saved.Meta = post.Meta
+	saved.Excerpt = post.Excerpt
+	saved.CoverImage = post.CoverImage
+	saved.Summary = models.Summarize(post.Content)
+	saved.WordCount = models.CountWords(post.Content)
+	saved.Updated = time.Now().UTC()
+
+	m.mu.Unlock()
+
+	mentions, hashtags := models.ParseReferences(post.Content)
+
+	err := m.SetReferences(post.Id, mentions, hashtags)
+	if err != nil {
+		return nil, err
+	}
+
+	var review *models.Review
+
+	if action != "" {
+		review, err = m.Transition(post.Id, actorId, action, "")
+		if err != nil {
+			return nil, err
+		}
+		post.Status = review.To
+	}
+
+	return review, nil
+}
+
+func (m *PostModel) Delete(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, ok := m.posts[id]
+	if !ok || !post.Deleted.IsZero() {
+		return models.ErrNoRecord
+	}
+
+	post.Deleted = time.Now().UTC()
+	return nil
+}
+
+func (m *PostModel) GetDeleted(userId int) ([]*models.Post, error) {
+	posts := m.list(func(p *models.Post) bool { return !p.Deleted.IsZero() && (userId == 0 || p.UserId == userId) })
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Deleted.After(posts[j].Deleted)
+	})
+
+	return posts, nil
+}
+
+func (m *PostModel) Restore(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, ok := m.posts[id]
+	if !ok || post.Deleted.IsZero() {
+		return models.ErrNoRecord
+	}
+
+	post.Deleted = time.Time{}
+	return nil
+}
+
+func (m *PostModel) DeletePermanently(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, ok := m.posts[id]
+	if !ok || post.Deleted.IsZero() {
+		return models.ErrNoRecord
+	}
+
+	delete(m.posts, id)
+	return nil
+}
+
+func (m *PostModel) Purge(retention time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := time.Now().UTC().Add(-retention)
+	n := 0
+
+	for id, post := range m.posts {
+		if !post.Deleted.IsZero() && post.Deleted.Before(cutoff) {
+			delete(m.posts, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// list returns copies of the posts matching keep, newest first.
+func (m *PostModel) list(keep func(*models.Post) bool) []*models.Post {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	posts := []*models.Post{}
+
+	for _, post := range m.posts {
+		if keep(post) {
+			p := *post
+			posts = append(posts, &p)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Id > posts[j].Id
+	})
+
+	return posts
+}
+
+func (m *PostModel) Transition(postId, actorId int, action, note string) (*models.Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, ok := m.posts[postId]
+	if !ok || !post.Deleted.IsZero() {
+		return nil, models.ErrNoRecord
+	}
+
+	to, err := models.NextStatus(post.Status, action)
+	if err != nil {
+		return nil, err
+	}
+
+	id := 1
+	if len(m.reviews) > 0 {
+		id = m.reviews[len(m.reviews)-1].Id + 1
+	}
+
+	review := &models.Review{
+		Id:      id,
+		PostId:  postId,
+		ActorId: actorId,
+		Action:  action,
+		From:    post.Status,
+		To:      to,
+		Note:    note,
+		Created: time.Now().UTC(),
+	}
+	m.reviews = append(m.reviews, review)
+	post.Status = to
+
+	r := *review
+	return &r, nil
+}
+
+// Reviews returns the review history of a post, or of every post if postId
+// is 0. The mock doesn't know usernames, so Actor is left empty.
+func (m *PostModel) Reviews(postId int) ([]*models.Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reviews := []*models.Review{}
+
+	for _, review := range m.reviews {
+		if postId == 0 || review.PostId == postId {
+			r := *review
+			reviews = append(reviews, &r)
+		}
+	}
+
+	return reviews, nil
+}
+
+func (m *PostModel) UpsertReview(review *models.Review) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := *review
+	r.Created = r.Created.UTC()
+
+	for i, existing := range m.reviews {
+		if existing.Id == r.Id {
+			m.reviews[i] = &r
+			return nil
+		}
+	}
+
+	m.reviews = append(m.reviews, &r)
+	sort.Slice(m.reviews, func(i, j int) bool { return m.reviews[i].Id < m.reviews[j].Id })
+
+	return nil
+}
+
+func (m *PostModel) React(postId, userId int, kind string) (bool, error) {
+	if !models.ValidReactionKind(kind) {
+		return false, models.ErrInvalidReaction
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, ok := m.posts[postId]
+	if !ok || !post.Deleted.IsZero() {
+		return false, models.ErrNoRecord
+	}
+
+	if m.reactions == nil {
+		m.reactions = map[reaction]time.Time{}
+	}
+
+	key := reaction{postId, userId, kind}
+
+	if _, ok := m.reactions[key]; ok {
+		delete(m.reactions, key)
+		post.Reactions--
+		return false, nil
+	}
+
+	m.reactions[key] = time.Now().UTC()
+	post.Reactions++
+	return true, nil
+}
+
+func (m *PostModel) Reactions(postId, userId int) ([]*models.ReactionCount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := []*models.ReactionCount{}
+
+	for _, kind := range models.ReactionKinds() {
+		count := &models.ReactionCount{Kind: kind}
+
+		for key := range m.reactions {
+			if key.postId == postId && key.kind == kind {
+				count.Count++
+				count.Reacted = count.Reacted || key.userId == userId
+			}
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+func (m *PostModel) AllReactions() ([]*models.Reaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reactions := []*models.Reaction{}
+
+	for key, created := range m.reactions {
+		reactions = append(reactions, &models.Reaction{PostId: key.postId, UserId: key.userId, Kind: key.kind,
+			Created: created})
+	}
+
+	sort.Slice(reactions, func(i, j int) bool {
+		a, b := reactions[i], reactions[j]
+		if a.PostId != b.PostId {
+			return a.PostId < b.PostId
+		}
+		if a.UserId != b.UserId {
+			return a.UserId < b.UserId
+		}
+		return a.Kind < b.Kind
+	})
+
+	return reactions, nil
+}
+
+func (m *PostModel) UpsertReaction(r *models.Reaction) error {
+	if !models.ValidReactionKind(r.Kind) {
+		return models.ErrInvalidReaction
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, ok := m.posts[r.PostId]
+	if !ok {
+		return models.ErrNoRecord
+	}
+
+	if m.reactions == nil {
+		m.reactions = map[reaction]time.Time{}
+	}
+
+	m.reactions[reaction{r.PostId, r.UserId, r.Kind}] = r.Created.UTC()
+
+	post.Reactions = 0
+	for key := range m.reactions {
+		if key.postId == r.PostId {
+			post.Reactions++
+		}
+	}
+
+	return nil
+}
+
+// setSeries makes post the given part of a series, like the real model's
+// setSeries. m must be locked.
+func (m *PostModel) setSeries(post *models.Post, seriesId, part int) {
+	if seriesId != 0 && part == 0 {
+		for _, p := range m.posts {
+			if p.SeriesId == seriesId && p.Id != post.Id && p.SeriesPart > part {
+				part = p.SeriesPart
+			}
+		}
+		part++
+	}
+
+	if seriesId == 0 {
+		part = 0
+	}
+
+	post.SeriesId = seriesId
+	post.SeriesPart = part
+}
+
+func (m *PostModel) GetBySeries(seriesId int) ([]*models.Post, error) {
+	posts := m.list(func(p *models.Post) bool { return p.SeriesId == seriesId && seriesId != 0 && p.Deleted.IsZero() })
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		if posts[i].SeriesPart != posts[j].SeriesPart {
+			return posts[i].SeriesPart < posts[j].SeriesPart
+		}
+		return posts[i].Id < posts[j].Id
+	})
+
+	return posts, nil
+}
+
+func (m *PostModel) Archive() ([]*models.ArchiveMonth, error) {
+	months := []*models.ArchiveMonth{}
+
+	posts, _ := m.GetAll()
+
+	for _, post := range posts {
+		year, month, _ := post.Created.Date()
+
+		i := slices.IndexFunc(months, func(a *models.ArchiveMonth) bool { return a.Year == year && a.Month == month })
+		if i < 0 {
+			months = append(months, &models.ArchiveMonth{Year: year, Month: month})
+			i = len(months) - 1
+		}
+
+		months[i].Posts++
+		if post.Updated.After(months[i].Updated) {
+			months[i].Updated = post.Updated
+		}
+	}
+
+	sort.Slice(months, func(i, j int) bool {
+		if months[i].Year != months[j].Year {
+			return months[i].Year > months[j].Year
+		}
+		return months[i].Month > months[j].Month
+	})
+
+	return months, nil
+}
+
+func (m *PostModel) GetBetween(from, to time.Time) ([]*models.Post, error) {
+	posts, _ := m.GetAll()
+
+	between := []*models.Post{}
+	for _, post := range posts {
+		if !post.Created.Before(from) && post.Created.Before(to) {
+			between = append(between, post)
+		}
+	}
+
+	sort.SliceStable(between, func(i, j int) bool { return between[i].Created.After(between[j].Created) })
+
+	return withoutContent(between), nil
+}
+
+func (m *PostModel) SetReferences(postId int, mentions, hashtags []string) error {
+	usernames := []string{}
+	for _, username := range mentions {
+		if user, err := m.Users.GetByUsername(username); err == nil {
+			usernames = append(usernames, user.Username)
+		}
+	}
+	slices.Sort(usernames)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, ok := m.posts[postId]
+	if !ok {
+		return models.ErrNoRecord
+	}
+
+	for _, username := range post.Mentions {
+		if !slices.Contains(usernames, username) {
+			delete(m.notified, mention{postId, username})
+		}
+	}
+
+	post.Mentions = usernames
+
+	if m.hashtags == nil {
+		m.hashtags = map[int][]string{}
+	}
+	m.hashtags[postId] = slices.Clone(hashtags)
+
+	return nil
+}
+
+func (m *PostModel) ClaimMentions(postId int) ([]*models.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	notifications := []*models.Notification{}
+
+	post, ok := m.posts[postId]
+	if !ok || !post.Deleted.IsZero() || post.Status != models.PostStatusPublished ||
+		post.Visibility == models.PostVisibilityPrivate {
+		return notifications, nil
+	}
+
+	if m.notified == nil {
+		m.notified = map[mention]bool{}
+	}
+
+	for _, username := range post.Mentions {
+		if m.notified[mention{postId, username}] {
+			continue
+		}
+
+		user, err := m.Users.GetByUsername(username)
+		if err != nil {
+			continue
+		}
+
+		m.notified[mention{postId, username}] = true
+		notifications = append(notifications, &models.Notification{UserId: user.Id, ActorId: post.UserId,
+			Kind: models.NotificationMention, PostId: postId})
+	}
+
+	return notifications, nil
+}
+
+func (m *PostModel) AllMentions() ([]*models.Mention, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mentions := []*models.Mention{}
+
+	for _, post := range m.posts {
+		for _, username := range post.Mentions {
+			user, err := m.Users.GetByUsername(username)
+			if err != nil {
+				continue
+			}
+
+			mentions = append(mentions, &models.Mention{PostId: post.Id, UserId: user.Id,
+				Notified: m.notified[mention{post.Id, username}]})
+		}
+	}
+
+	sort.Slice(mentions, func(i, j int) bool {
+		if mentions[i].PostId != mentions[j].PostId {
+			return mentions[i].PostId < mentions[j].PostId
+		}
+		return mentions[i].UserId < mentions[j].UserId
+	})
+
+	return mentions, nil
+}
+
+func (m *PostModel) UpsertMention(in *models.Mention) error {
+	user, err := m.Users.Get(in.UserId)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	post, ok := m.posts[in.PostId]
+	if !ok {
+		return models.ErrNoRecord
+	}
+
+	if !slices.Contains(post.Mentions, user.Username) {
+		post.Mentions = append(slices.Clone(post.Mentions), user.Username)
+		slices.Sort(post.Mentions)
+	}
+
+	if m.notified == nil {
+		m.notified = map[mention]bool{}
+	}
+	m.notified[mention{in.PostId, user.Username}] = in.Notified
+
+	return nil
+}
+
+func (m *PostModel) LatestByHashtag(tag string) ([]*models.Post, error) {
+	tag = strings.ToLower(tag)
+
+	return withoutContent(m.list(func(p *models.Post) bool {
+		return p.Deleted.IsZero() && p.Status == models.PostStatusPublished && p.Visibility == models.PostVisibilityPublic &&
+			slices.Contains(m.hashtags[p.Id], tag)
+	})), nil
+}
+
+func (m *PostModel) Hashtags() ([]*models.Hashtag, error) {
+	posts, _ := m.GetAll()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	updated := map[string]time.Time{}
+	for _, post := range posts {
+		for _, tag := range m.hashtags[post.Id] {
+			if post.Updated.After(updated[tag]) {
+				updated[tag] = post.Updated
+			}
+		}
+	}
+
+	hashtags := []*models.Hashtag{}
+	for tag, t := range updated {
+		hashtags = append(hashtags, &models.Hashtag{Tag: tag, Updated: t})
+	}
+	sort.Slice(hashtags, func(i, j int) bool { return hashtags[i].Tag < hashtags[j].Tag })
+
+	return hashtags, nil
+}
@@ -0,0 +1,120 @@
+package mocks
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+)
+
+// SeriesModel is an in-memory implementation of
+// models.SeriesModelInterface. The mock doesn't know usernames, so Author
+// is left empty.
+type SeriesModel struct {
+	mu     sync.Mutex
+	series []*models.Series
+}
+
+// insert starts series for PostModel.Save and sets its ID, adding a number
+// to its slug if another series already uses it.
+func (m *SeriesModel) insert(series *models.Series) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	base := series.Slug
+	for i := 2; slices.ContainsFunc(m.series, func(s *models.Series) bool { return s.Slug == series.Slug }); i++ {
+		series.Slug = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	series.Id = 1
+	if len(m.series) > 0 {
+		series.Id = m.series[len(m.series)-1].Id + 1
+	}
+
+	s := *series
+	s.Created = time.Now().UTC()
+	m.series = append(m.series, &s)
+}
+
+func (m *SeriesModel) Get(id int) (*models.Series, error) {
+	return m.find(func(s *models.Series) bool { return s.Id == id })
+}
+
+func (m *SeriesModel) GetBySlug(slug string) (*models.Series, error) {
+	return m.find(func(s *models.Series) bool { return s.Slug == slug })
+}
+
+func (m *SeriesModel) find(match func(*models.Series) bool) (*models.Series, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, series := range m.series {
+		if match(series) {
+			s := *series
+			return &s, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (m *SeriesModel) GetByUser(userId int) ([]*models.Series, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	all := []*models.Series{}
+
+	for _, series := range m.series {
+		if series.UserId == userId {
+			s := *series
+			all = append(all, &s)
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Title < all[j].Title })
+
+	return all, nil
+}
+
+func (m *SeriesModel) GetAll() ([]*models.Series, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	all := []*models.Series{}
+
+	for _, series := range m.series {
+		s := *series
+		all = append(all, &s)
+	}
+
+	return all, nil
+}
+
+func (m *SeriesModel) Upsert(series *models.Series) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.series {
+		if s.Slug == series.Slug && s.Id != series.Id {
+			return models.ErrDuplicateSlug
+		}
+	}
+
+	s := *series
+	s.Created = s.Created.UTC()
+
+	for i, existing := range m.series {
+		if existing.Id == s.Id {
+			m.series[i] = &s
+			return nil
+		}
+	}
+
+	m.series = append(m.series, &s)
+	sort.Slice(m.series, func(i, j int) bool { return m.series[i].Id < m.series[j].Id })
+
+	return nil
+}
@@ -0,0 +1,388 @@
+package mocks
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserModel is an in-memory implementation of models.UserModelInterface.
+// The zero value is an empty model ready to use.
+type UserModel struct {
+	mu      sync.Mutex
+	users   []*models.User
+	follows []follow
+	since   map[follow]time.Time
+	nextId  int
+}
+
+type follow struct {
+	followerId, followedId int
+}
+
+func (m *UserModel) Insert(username, email, password string) error {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if user.Username == username {
+			return models.ErrDuplicateUsername
+		}
+		if user.Email == email {
+			return models.ErrDuplicateEmail
+		}
+	}
+
+	m.nextId++
+	m.users = append(m.users, &models.User{
+		Id:           m.nextId,
+		Username:     username,
+		Email:        email,
+		PasswordHash: passwordHash,
+		Role:         models.RoleUser,
+	})
+
+	return nil
+}
+
+func (m *UserModel) Authenticate(username, password string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if user.Username != username {
+			continue
+		}
+
+		err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password))
+		if err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return 0, models.ErrInvalidCredentials
+			}
+			return 0, err
+		}
+
+		if user.Disabled {
+			return 0, models.ErrAccountDisabled
+		}
+
+		return user.Id, nil
+	}
+
+	return 0, models.ErrInvalidCredentials
+}
+
+func (m *UserModel) Exists(id int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if user.Id == id {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (m *UserModel) Get(id int) (*models.User, error) {
+	return m.find(func(u *models.User) bool { return u.Id == id })
+}
+
+func (m *UserModel) GetByUsername(username string) (*models.User, error) {
+	return m.find(func(u *models.User) bool { return u.Username == username })
+}
+
+func (m *UserModel) GetByEmail(email string) (*models.User, error) {
+	return m.find(func(u *models.User) bool { return u.Email == email })
+}
+
+func (m *UserModel) find(match func(*models.User) bool) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if match(user) {
+			u := *user
+			return &u, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (m *UserModel) GetAll() ([]*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users := []*models.User{}
+	for _, user := range m.users {
+		u := *user
+		users = append(users, &u)
+	}
+
+	return users, nil
+}
+
+func (m *UserModel) Upsert(user *models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.users {
+		if existing.Id == user.Id {
+			existing.Username = user.Username
+			existing.Email = user.Email
+			existing.Role = user.Role
+			existing.Disabled = user.Disabled
+			existing.Language = user.Language
+			existing.TimeZone = user.TimeZone
+			if len(user.PasswordHash) > 0 {
+				existing.PasswordHash = user.PasswordHash
+			}
+			return nil
+		}
+	}
+
+	u := *user
+	if u.Role == "" {
+		u.Role = models.RoleUser
+	}
+	m.users = append(m.users, &u)
+
+	if u.Id > m.nextId {
+		m.nextId = u.Id
+	}
+
+	return nil
+}
+
+func (m *UserModel) Search(query string) ([]*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users := []*models.User{}
+	for _, user := range m.users {
+		if strings.Contains(user.Username, query) || strings.Contains(user.Email, query) {
+			u := *user
+			users = append(users, &u)
+		}
+	}
+
+	return users, nil
+}
+
+func (m *UserModel) SetDisabled(id int, disabled bool) error {
+	return m.update(id, func(u *models.User) error {
+		u.Disabled = disabled
+		return nil
+	})
+}
+
+func (m *UserModel) SetRole(id int, role string) error {
+	if !models.ValidRole(role) {
+		return models.ErrInvalidRole
+	}
+
+	return m.update(id, func(u *models.User) error {
+		u.Role = role
+		return nil
+	})
+}
+
+func (m *UserModel) SetPassword(id int, password string) error {
+	return m.update(id, func(u *models.User) error {
+		passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		u.PasswordHash = passwordHash
+		return err
+	})
+}
+
+func (m *UserModel) SetPreferences(id int, language, timeZone string) error {
+	return m.update(id, func(u *models.User) error {
+		u.Language = language
+		u.TimeZone = timeZone
+		return nil
+	})
+}
+
+func (m *UserModel) update(id int, update func(*models.User) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if user.Id == id {
+			return update(user)
+		}
+	}
+
+	return models.ErrNoRecord
+}
+
+func (m *UserModel) Stats() (*models.UserStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := &models.UserStats{Total: len(m.users)}
+
+	for _, user := range m.users {
+		if user.Role == models.RoleAdmin {
+			stats.Admins++
+		}
+		if user.Disabled {
+			stats.Disabled++
+		}
+	}
+
+	return stats, nil
+}
+
+func (m *UserModel) Follow(followerId, followedId int) error {
+	if followerId == followedId {
+		return models.ErrSelfFollow
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.exists(followerId) || !m.exists(followedId) {
+		return models.ErrNoRecord
+	}
+
+	f := follow{followerId, followedId}
+	if !slices.Contains(m.follows, f) {
+		m.follows = append(m.follows, f)
+		m.setSince(f, time.Now().UTC())
+	}
+
+	return nil
+}
+
+func (m *UserModel) setSince(f follow, created time.Time) {
+	if m.since == nil {
+		m.since = map[follow]time.Time{}
+	}
+	m.since[f] = created
+}
+
+func (m *UserModel) AllFollows() ([]*models.Follow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	follows := []*models.Follow{}
+	for _, f := range m.follows {
+		follows = append(follows, &models.Follow{FollowerId: f.followerId, FollowedId: f.followedId, Created: m.since[f]})
+	}
+
+	return follows, nil
+}
+
+func (m *UserModel) UpsertFollow(f *models.Follow) error {
+	if f.FollowerId == f.FollowedId {
+		return models.ErrSelfFollow
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.exists(f.FollowerId) || !m.exists(f.FollowedId) {
+		return models.ErrNoRecord
+	}
+
+	key := follow{f.FollowerId, f.FollowedId}
+	if !slices.Contains(m.follows, key) {
+		m.follows = append(m.follows, key)
+	}
+	m.setSince(key, f.Created.UTC())
+
+	return nil
+}
+
+func (m *UserModel) Unfollow(followerId, followedId int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.follows = slices.DeleteFunc(m.follows, func(f follow) bool { return f == follow{followerId, followedId} })
+
+	return nil
+}
+
+func (m *UserModel) IsFollowing(followerId, followedId int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Contains(m.follows, follow{followerId, followedId}), nil
+}
+
+func (m *UserModel) FollowCounts(userId int) (*models.FollowCounts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := &models.FollowCounts{}
+
+	for _, f := range m.follows {
+		if f.followedId == userId {
+			counts.Followers++
+		}
+		if f.followerId == userId {
+			counts.Following++
+		}
+	}
+
+	return counts, nil
+}
+
+func (m *UserModel) Followers(userId int) ([]*models.User, error) {
+	return m.followList(func(f follow) (int, bool) { return f.followerId, f.followedId == userId }), nil
+}
+
+func (m *UserModel) Following(userId int) ([]*models.User, error) {
+	return m.followList(func(f follow) (int, bool) { return f.followedId, f.followerId == userId }), nil
+}
+
+// FollowedIds returns the IDs of the users userId follows. It lets the
+// mock PostModel build timelines.
+func (m *UserModel) FollowedIds(userId int) []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := []int{}
+	for _, f := range m.follows {
+		if f.followerId == userId {
+			ids = append(ids, f.followedId)
+		}
+	}
+
+	return ids
+}
+
+func (m *UserModel) followList(match func(follow) (int, bool)) []*models.User {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users := []*models.User{}
+
+	for _, user := range m.users {
+		for _, f := range m.follows {
+			if id, ok := match(f); ok && id == user.Id {
+				u := *user
+				users = append(users, &u)
+			}
+		}
+	}
+
+	return users
+}
+
+func (m *UserModel) exists(id int) bool {
+	return slices.ContainsFunc(m.users, func(u *models.User) bool { return u.Id == id })
+}
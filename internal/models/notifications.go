@@ -0,0 +1,249 @@
+package models
+
+import (
+	"database/sql"
+	"slices"
+	"strconv"
+	"time"
+)
+
+type NotificationModelInterface interface {
+	Insert(n *Notification) error
+	List(userId, limit int) ([]*Notification, error)
+	Unread(userId int) (int, error)
+	MarkRead(userId, id int) error
+	Muted(userId int) ([]string, error)
+	SetMuted(userId int, kinds []string) error
+	GetAll() ([]*Notification, error)
+	Upsert(n *Notification) error
+	AllMuted() (map[int][]string, error)
+}
+
+// Notification kinds are the events users are told about. Users can turn
+// each kind off in their settings.
+const (
+	NotificationFollow   = "follow"
+	NotificationReaction = "reaction"
+	NotificationReview   = "review"
+	NotificationMention  = "mention"
+)
+
+// NotificationKinds returns every kind of notification.
+func NotificationKinds() []string {
+	return []string{NotificationFollow, NotificationReaction, NotificationReview, NotificationMention}
+}
+
+func ValidNotificationKind(kind string) bool {
+	return slices.Contains(NotificationKinds(), kind)
+}
+
+// Notification tells UserId that ActorId did something, usually to one of
+// their posts. Detail depends on the kind: the kind of reaction, or the
+// review action taken.
+type Notification struct {
+	Id        int
+	UserId    int
+	ActorId   int
+	Actor     string
+	Kind      string
+	PostId    int
+	PostTitle string
+	Detail    string
+	Created   time.Time
+	Read      bool
+}
+
+type NotificationModel struct {
+	DB *sql.DB
+}
+
+// Insert records n unless its user has turned its kind off.
+func (m *NotificationModel) Insert(n *Notification) error {
+	if !ValidNotificationKind(n.Kind) {
+		return ErrInvalidNotification
+	}
+
+	stmt := `INSERT INTO notifications (user_id, actor_id, kind, post_id, detail, created)
+	SELECT ?, NULLIF(?, 0), ?, NULLIF(?, 0), ?, UTC_TIMESTAMP() FROM DUAL
+	WHERE NOT EXISTS (SELECT true FROM notification_mutes WHERE user_id = ? AND kind = ?)`
+
+	_, err := m.DB.Exec(stmt, n.UserId, n.ActorId, n.Kind, n.PostId, n.Detail, n.UserId, n.Kind)
+	return err
+}
+
+// List returns up to limit of the user's notifications, newest first.
+func (m *NotificationModel) List(userId, limit int) ([]*Notification, error) {
+	stmt := `SELECT n.id, n.user_id, COALESCE(n.actor_id, 0), COALESCE(u.username, ''), n.kind,
+	COALESCE(n.post_id, 0), COALESCE(p.title, ''), n.detail, n.created, n.read_at IS NOT NULL
+	FROM notifications n LEFT JOIN users u ON u.id = n.actor_id LEFT JOIN posts p ON p.id = n.post_id
+	WHERE n.user_id = ? ORDER BY n.id DESC LIMIT ` + strconv.Itoa(limit)
+
+	rows, err := m.DB.Query(stmt, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*Notification{}
+
+	for rows.Next() {
+		n := &Notification{}
+
+		err = rows.Scan(&n.Id, &n.UserId, &n.ActorId, &n.Actor, &n.Kind,
+			&n.PostId, &n.PostTitle, &n.Detail, &n.Created, &n.Read)
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// Unread returns how many of the user's notifications haven't been read.
+func (m *NotificationModel) Unread(userId int) (int, error) {
+	var n int
+
+	stmt := "SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL"
+
+	err := m.DB.QueryRow(stmt, userId).Scan(&n)
+	return n, err
+}
+
+// MarkRead marks the user's notification with the given ID as read, or all
+// of their notifications if id is 0. Other users' notifications are left
+// alone.
+func (m *NotificationModel) MarkRead(userId, id int) error {
+	stmt := `UPDATE notifications SET read_at = UTC_TIMESTAMP()
+	WHERE user_id = ? AND (? = 0 OR id = ?) AND read_at IS NULL`
+
+	_, err := m.DB.Exec(stmt, userId, id, id)
+	return err
+}
+
+// GetAll returns every user's notifications, oldest first, for backups.
+// Actor and PostTitle are left empty.
+func (m *NotificationModel) GetAll() ([]*Notification, error) {
+	stmt := `SELECT id, user_id, COALESCE(actor_id, 0), kind, COALESCE(post_id, 0), detail, created,
+	read_at IS NOT NULL FROM notifications ORDER BY id`
+
+	rows, err := m.DB.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*Notification{}
+
+	for rows.Next() {
+		n := &Notification{}
+
+		err = rows.Scan(&n.Id, &n.UserId, &n.ActorId, &n.Kind, &n.PostId, &n.Detail, &n.Created, &n.Read)
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// Upsert inserts n with its ID, or overwrites the notification with that
+// ID if it already exists, whether or not its user has turned its kind
+// off. It is used to restore backups. Notifications that were read are
+// marked as read now.
+func (m *NotificationModel) Upsert(n *Notification) error {
+	if !ValidNotificationKind(n.Kind) {
+		return ErrInvalidNotification
+	}
+
+	stmt := `INSERT INTO notifications (id, user_id, actor_id, kind, post_id, detail, created, read_at)
+	VALUES(?, ?, NULLIF(?, 0), ?, NULLIF(?, 0), ?, ?, IF(?, UTC_TIMESTAMP(), NULL)) AS new
+	ON DUPLICATE KEY UPDATE user_id = new.user_id, actor_id = new.actor_id, kind = new.kind,
+	post_id = new.post_id, detail = new.detail, created = new.created,
+	read_at = IF(new.read_at IS NULL, NULL, COALESCE(notifications.read_at, new.read_at))`
+
+	_, err := m.DB.Exec(stmt, n.Id, n.UserId, n.ActorId, n.Kind, n.PostId, n.Detail, n.Created.UTC(), n.Read)
+	return err
+}
+
+// Muted returns the kinds of notifications the user has turned off.
+func (m *NotificationModel) Muted(userId int) ([]string, error) {
+	rows, err := m.DB.Query("SELECT kind FROM notification_mutes WHERE user_id = ? ORDER BY kind", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	kinds := []string{}
+
+	for rows.Next() {
+		var kind string
+
+		err = rows.Scan(&kind)
+		if err != nil {
+			return nil, err
+		}
+
+		kinds = append(kinds, kind)
+	}
+
+	return kinds, rows.Err()
+}
+
+// AllMuted returns the kinds of notifications each user has turned off,
+// for backups. Users who haven't turned any off are left out.
+func (m *NotificationModel) AllMuted() (map[int][]string, error) {
+	rows, err := m.DB.Query("SELECT user_id, kind FROM notification_mutes ORDER BY user_id, kind")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	muted := map[int][]string{}
+
+	for rows.Next() {
+		var userId int
+		var kind string
+
+		err = rows.Scan(&userId, &kind)
+		if err != nil {
+			return nil, err
+		}
+
+		muted[userId] = append(muted[userId], kind)
+	}
+
+	return muted, rows.Err()
+}
+
+// SetMuted replaces the kinds of notifications the user has turned off.
+func (m *NotificationModel) SetMuted(userId int, kinds []string) error {
+	for _, kind := range kinds {
+		if !ValidNotificationKind(kind) {
+			return ErrInvalidNotification
+		}
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM notification_mutes WHERE user_id = ?", userId)
+	if err != nil {
+		return err
+	}
+
+	for _, kind := range kinds {
+		_, err = tx.Exec("INSERT INTO notification_mutes (user_id, kind) VALUES(?, ?)", userId, kind)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
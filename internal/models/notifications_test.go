@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestNotificationModel(t *testing.T) {
+	db := newTestDB(t)
+
+	users := UserModel{DB: db, BcryptCost: 4}
+	m := NotificationModel{DB: db}
+
+	assert.NilError(t, users.Insert("dave", "dave@example.com", "pa$$word"))
+	dave, err := users.GetByUsername("dave")
+	assert.NilError(t, err)
+
+	assert.NilError(t, m.Insert(&Notification{UserId: 1, ActorId: dave.Id, Kind: NotificationFollow}))
+	assert.NilError(t, m.Insert(&Notification{UserId: 1, ActorId: dave.Id, Kind: NotificationReaction, PostId: 1, Detail: ReactionLike}))
+	assert.Equal(t, m.Insert(&Notification{UserId: 1, Kind: "comment"}), ErrInvalidNotification)
+
+	notifications, err := m.List(1, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(notifications), 2)
+	assert.Equal(t, notifications[0].Kind, NotificationReaction)
+	assert.Equal(t, notifications[0].Actor, "dave")
+	assert.Equal(t, notifications[0].PostTitle, "An old silent pond")
+	assert.Equal(t, notifications[0].Read, false)
+	assert.Equal(t, notifications[1].PostId, 0)
+
+	unread, err := m.Unread(1)
+	assert.NilError(t, err)
+	assert.Equal(t, unread, 2)
+
+	// Users can't mark others' notifications as read.
+	assert.NilError(t, m.MarkRead(dave.Id, notifications[0].Id))
+	assert.NilError(t, m.MarkRead(1, notifications[1].Id))
+
+	unread, err = m.Unread(1)
+	assert.NilError(t, err)
+	assert.Equal(t, unread, 1)
+
+	assert.NilError(t, m.MarkRead(1, 0))
+
+	unread, err = m.Unread(1)
+	assert.NilError(t, err)
+	assert.Equal(t, unread, 0)
+
+	assert.NilError(t, m.SetMuted(1, []string{NotificationReaction}))
+	assert.Equal(t, m.SetMuted(1, []string{"comment"}), ErrInvalidNotification)
+
+	muted, err := m.Muted(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(muted), 1)
+	assert.Equal(t, muted[0], NotificationReaction)
+
+	assert.NilError(t, m.Insert(&Notification{UserId: 1, ActorId: dave.Id, Kind: NotificationReaction, PostId: 1, Detail: ReactionWow}))
+
+	unread, err = m.Unread(1)
+	assert.NilError(t, err)
+	assert.Equal(t, unread, 0)
+}
+
+func TestNotificationModelUpsert(t *testing.T) {
+	db := newTestDB(t)
+
+	m := NotificationModel{DB: db}
+
+	created := time.Date(2024, 3, 18, 9, 0, 0, 0, time.UTC)
+	n := &Notification{Id: 7, UserId: 1, Kind: NotificationReaction, PostId: 1, Detail: ReactionLike, Created: created}
+
+	assert.NilError(t, m.SetMuted(1, []string{NotificationReaction}))
+	assert.NilError(t, m.Upsert(n))
+	n.Read = true
+	assert.NilError(t, m.Upsert(n))
+	assert.Equal(t, m.Upsert(&Notification{Id: 8, UserId: 1, Kind: "comment"}), ErrInvalidNotification)
+
+	all, err := m.GetAll()
+	assert.NilError(t, err)
+	assert.Equal(t, len(all), 1)
+	assert.Equal(t, *all[0], *n)
+
+	muted, err := m.AllMuted()
+	assert.NilError(t, err)
+	assert.Equal(t, len(muted), 1)
+	assert.Equal(t, muted[1][0], NotificationReaction)
+}
@@ -3,26 +3,208 @@ package models
 import (
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PostModelInterface interface {
+	Import(post *Post) (int, error)
+	Get(id int) (*Post, error)
+	GetBySlug(slug string) (*Post, error)
+	GetByUser(userId int) ([]*Post, error)
+	GetEverything() ([]*Post, error)
+	Upsert(post *Post) error
+	List(status string) ([]*Post, error)
+	SetStatus(ids []int, status string) (int, error)
+	Reassign(ids []int, userId int) (int, error)
+	CountSince(userId int, since time.Time) (int, error)
+	Stats() (*PostStats, error)
+	GetAll() ([]*Post, error)
+	Latest(sort string) ([]*Post, error)
+	LatestByAuthor(userId int) ([]*Post, error)
+	Timeline(userId, before, limit int) ([]*Post, error)
+	Archive() ([]*ArchiveMonth, error)
+	GetBetween(from, to time.Time) ([]*Post, error)
+	Save(post *Post, series *Series, actorId int, password, action string) (*Review, error)
+	Delete(id int) error
+	GetDeleted(userId int) ([]*Post, error)
+	Restore(id int) error
+	DeletePermanently(id int) error
+	Purge(retention time.Duration) (int, error)
+	Transition(postId, actorId int, action, note string) (*Review, error)
+	Reviews(postId int) ([]*Review, error)
+	UpsertReview(review *Review) error
+	GetBySeries(seriesId int) ([]*Post, error)
+	React(postId, userId int, kind string) (bool, error)
+	Reactions(postId, userId int) ([]*ReactionCount, error)
+	AllReactions() ([]*Reaction, error)
+	UpsertReaction(reaction *Reaction) error
+	SetReferences(postId int, mentions, hashtags []string) error
+	ClaimMentions(postId int) ([]*Notification, error)
+	AllMentions() ([]*Mention, error)
+	UpsertMention(mention *Mention) error
+	LatestByHashtag(tag string) ([]*Post, error)
+	Hashtags() ([]*Hashtag, error)
+}
+
+// Post statuses. Flagged posts were held back by the spam filter and wait
+// for a moderator. Posts in review wait for a reviewer, who may send them
+// back to their author with changes requested; see Transition.
+const (
+	PostStatusPublished        = "published"
+	PostStatusDraft            = "draft"
+	PostStatusFlagged          = "flagged"
+	PostStatusInReview         = "review"
+	PostStatusChangesRequested = "changes"
+)
+
+func ValidPostStatus(status string) bool {
+	switch status {
+	case PostStatusPublished, PostStatusDraft, PostStatusFlagged, PostStatusInReview, PostStatusChangesRequested:
+		return true
+	}
+	return false
+}
+
+// Post visibilities. Unlisted posts are left out of listings but can be
+// read by anyone with the link, private posts only by their author and
+// users who may view private posts, and password-protected posts by
+// anyone who knows the post's passphrase.
+const (
+	PostVisibilityPublic    = "public"
+	PostVisibilityUnlisted  = "unlisted"
+	PostVisibilityPrivate   = "private"
+	PostVisibilityProtected = "password"
 )
 
+// PostVisibilities returns the names of all visibilities, from most to
+// least visible.
+func PostVisibilities() []string {
+	return []string{PostVisibilityPublic, PostVisibilityUnlisted, PostVisibilityPrivate, PostVisibilityProtected}
+}
+
+func ValidPostVisibility(visibility string) bool {
+	switch visibility {
+	case PostVisibilityPublic, PostVisibilityUnlisted, PostVisibilityPrivate, PostVisibilityProtected:
+		return true
+	}
+	return false
+}
+
 type Post struct {
-	Id      int
-	Title   string
-	Content string
+	Id         int
+	UserId     int
+	Author     string
+	Slug       string
+	Title      string
+	Content    string
+	Tags       []string
+	Mentions   []string
+	Status     string
+	Visibility string
+	// PasswordHash is the bcrypt hash of the passphrase of a
+	// password-protected post. It may be empty, in which case nobody can
+	// unlock the post until a passphrase is set.
+	PasswordHash []byte
+	// SeriesId is the series the post belongs to, if any, and SeriesPart
+	// its position in it.
+	SeriesId   int
+	SeriesPart int
+	Meta       PostMeta
+	// Excerpt is the author's summary of the post for listings and
+	// CoverImage the absolute URL of an image shown with it. Summary and
+	// WordCount are worked out from the content whenever it is saved.
+	Excerpt    string
+	CoverImage string
+	Summary    string
+	WordCount  int
+	// Reactions is the number of reactions to the post of all kinds.
+	Reactions int
+	// Created is when the post was written and never changes, so that
+	// editing a post doesn't move it in listings or the archive. Updated
+	// is when it was last saved, which is Created until it is edited.
 	Created time.Time
+	Updated time.Time
+	Deleted time.Time
+}
+
+// Teaser returns the text shown for the post in listings: its excerpt, or
+// the summary of its content if it has none.
+func (p *Post) Teaser() string {
+	if p.Excerpt != "" {
+		return p.Excerpt
+	}
+	return p.Summary
+}
+
+// wordsPerMinute is the reading speed assumed by ReadingTime.
+const wordsPerMinute = 200
+
+// ReadingTime estimates how many minutes it takes to read the post, which
+// is at least one.
+func (p *Post) ReadingTime() int {
+	return max(1, (p.WordCount+wordsPerMinute-1)/wordsPerMinute)
+}
+
+// CheckPassword reports whether password is the post's passphrase.
+func (p *Post) CheckPassword(password string) bool {
+	if len(p.PasswordHash) == 0 {
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword(p.PasswordHash, []byte(password)) == nil
+}
+
+// PostMeta overrides what is shown when a post is shared on social media
+// or found by search engines. Empty fields fall back to the post's title,
+// the start of its content and no image.
+type PostMeta struct {
+	Title       string
+	Description string
+	// Image is the absolute URL of an image.
+	Image string
+}
+
+type PostStats struct {
+	Published int
+	Drafts    int
+	Flagged   int
+	InReview  int
+	Trashed   int
+	Tags      int
 }
 
 type PostModel struct {
-	DB *sql.DB
+	DB         *sql.DB
+	BcryptCost int
 }
 
-func (m *PostModel) Insert(title, content string) (int, error) {
-	stmt := `INSERT INTO posts (title, content, created)
-	VALUES(?, ?, UTC_TIMESTAMP())`
+// Import inserts a post brought in from elsewhere, keeping its author,
+// slug, tags and original creation time.
+func (m *PostModel) Import(post *Post) (int, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO posts (user_id, slug, title, content, status, visibility, password_hash,
+	meta_title, meta_description, meta_image, excerpt, cover_image, summary, word_count, created, updated)
+	VALUES(NULLIF(?, 0), NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := m.DB.Exec(stmt, title, content)
+	result, err := tx.Exec(stmt, post.UserId, post.Slug, post.Title, post.Content, statusOrDefault(post.Status),
+		visibilityOrDefault(post.Visibility), string(post.PasswordHash),
+		post.Meta.Title, post.Meta.Description, post.Meta.Image,
+		post.Excerpt, post.CoverImage, Summarize(post.Content), CountWords(post.Content), post.Created.UTC(),
+		updatedOrCreated(post).UTC())
 	if err != nil {
+		var mySQLError *mysql.MySQLError
+		if errors.As(err, &mySQLError) && mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "posts_uc_slug") {
+			return 0, ErrDuplicateSlug
+		}
 		return 0, err
 	}
 
@@ -31,17 +213,140 @@ func (m *PostModel) Insert(title, content string) (int, error) {
 		return 0, err
 	}
 
+	err = setTags(tx, int(id), post.Tags)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
 	return int(id), nil
 }
 
+// Upsert inserts post with its ID, or overwrites the post with that ID if
+// it already exists. It is used to restore backups. An existing post's
+// passphrase is kept if post has no password hash, so that restoring a
+// backup without secrets doesn't unlock protected posts.
+func (m *PostModel) Upsert(post *Post) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var deleted sql.NullTime
+	if !post.Deleted.IsZero() {
+		deleted = sql.NullTime{Time: post.Deleted.UTC(), Valid: true}
+	}
+
+	stmt := `INSERT INTO posts (id, user_id, slug, title, content, status, visibility, password_hash,
+	series_id, series_part, meta_title, meta_description, meta_image, excerpt, cover_image, summary, word_count,
+	created, updated, deleted)
+	VALUES(?, NULLIF(?, 0), NULLIF(?, ''), ?, ?, ?, ?, ?, NULLIF(?, 0), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) AS new
+	ON DUPLICATE KEY UPDATE user_id = new.user_id, slug = new.slug, title = new.title,
+	content = new.content, status = new.status, visibility = new.visibility,
+	password_hash = IF(?, posts.password_hash, new.password_hash), series_id = new.series_id,
+	series_part = new.series_part, meta_title = new.meta_title, meta_description = new.meta_description,
+	meta_image = new.meta_image, excerpt = new.excerpt, cover_image = new.cover_image, summary = new.summary,
+	word_count = new.word_count, created = new.created, updated = new.updated, deleted = new.deleted`
+
+	_, err = tx.Exec(stmt, post.Id, post.UserId, post.Slug, post.Title, post.Content, statusOrDefault(post.Status),
+		visibilityOrDefault(post.Visibility), string(post.PasswordHash), post.SeriesId, post.SeriesPart,
+		post.Meta.Title, post.Meta.Description, post.Meta.Image,
+		post.Excerpt, post.CoverImage, Summarize(post.Content), CountWords(post.Content), post.Created.UTC(),
+		updatedOrCreated(post).UTC(), deleted, len(post.PasswordHash) == 0)
+	if err != nil {
+		var mySQLError *mysql.MySQLError
+		if errors.As(err, &mySQLError) && mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "posts_uc_slug") {
+			return ErrDuplicateSlug
+		}
+		return err
+	}
+
+	err = setTags(tx, post.Id, post.Tags)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// updatedOrCreated returns when post was last saved, for posts imported
+// from sources that only know when they were created.
+func updatedOrCreated(post *Post) time.Time {
+	if post.Updated.IsZero() {
+		return post.Created
+	}
+	return post.Updated
+}
+
+func statusOrDefault(status string) string {
+	if status == "" {
+		return PostStatusPublished
+	}
+	return status
+}
+
+func visibilityOrDefault(visibility string) string {
+	if visibility == "" {
+		return PostVisibilityPublic
+	}
+	return visibility
+}
+
+// setTags replaces the tags of a post, creating any tags that don't exist
+// yet.
+func setTags(tx *sql.Tx, postId int, tags []string) error {
+	_, err := tx.Exec("DELETE FROM post_tags WHERE post_id = ?", postId)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		_, err = tx.Exec("INSERT INTO tags (name) VALUES (?) ON DUPLICATE KEY UPDATE name = name", tag)
+		if err != nil {
+			return err
+		}
+
+		stmt := `INSERT IGNORE INTO post_tags (post_id, tag_id)
+		SELECT ?, id FROM tags WHERE name = ?`
+
+		_, err = tx.Exec(stmt, postId, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *PostModel) Get(id int) (*Post, error) {
-	stmt := "SELECT id, title, content, created FROM posts WHERE id = ?"
+	return m.getWhere("p.id = ?", id)
+}
+
+func (m *PostModel) GetBySlug(slug string) (*Post, error) {
+	return m.getWhere("p.slug = ?", slug)
+}
 
-	row := m.DB.QueryRow(stmt, id)
+func (m *PostModel) getWhere(cond string, args ...any) (*Post, error) {
+	stmt := `SELECT p.id, COALESCE(p.user_id, 0), COALESCE(u.username, ''), COALESCE(p.slug, ''),
+	p.title, p.content, p.status, p.visibility, p.password_hash, COALESCE(p.series_id, 0), p.series_part,
+	p.meta_title, p.meta_description, p.meta_image, p.excerpt, p.cover_image, p.summary, p.word_count,
+	p.reaction_count, p.created, p.updated
+	FROM posts p LEFT JOIN users u ON u.id = p.user_id
+	WHERE ` + cond + ` AND p.deleted IS NULL`
+
+	row := m.DB.QueryRow(stmt, args...)
 
 	post := &Post{}
 
-	err := row.Scan(&post.Id, &post.Title, &post.Content, &post.Created)
+	err := row.Scan(&post.Id, &post.UserId, &post.Author, &post.Slug, &post.Title, &post.Content, &post.Status,
+		&post.Visibility, &post.PasswordHash, &post.SeriesId, &post.SeriesPart,
+		&post.Meta.Title, &post.Meta.Description, &post.Meta.Image,
+		&post.Excerpt, &post.CoverImage, &post.Summary, &post.WordCount, &post.Reactions, &post.Created, &post.Updated)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNoRecord
@@ -50,11 +355,51 @@ func (m *PostModel) Get(id int) (*Post, error) {
 		}
 	}
 
+	post.Tags, err = m.tags(post.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	post.Mentions, err = m.mentions(post.Id)
+	if err != nil {
+		return nil, err
+	}
+
 	return post, nil
 }
 
+func (m *PostModel) tags(postId int) ([]string, error) {
+	stmt := `SELECT t.name FROM tags t
+	INNER JOIN post_tags pt ON pt.tag_id = t.id
+	WHERE pt.post_id = ? ORDER BY t.name`
+
+	rows, err := m.DB.Query(stmt, postId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+
+	for rows.Next() {
+		var tag string
+
+		err = rows.Scan(&tag)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// GetAll returns the published, public posts with their content, newest
+// first. Listings should use Latest, which leaves the content out.
 func (m *PostModel) GetAll() ([]*Post, error) {
-	stmt := "SELECT id, title, content, created FROM posts ORDER BY id DESC"
+	stmt := `SELECT id, title, content, excerpt, cover_image, summary, word_count, reaction_count, created FROM posts
+	WHERE deleted IS NULL AND status = 'published' AND visibility = 'public' ORDER BY id DESC`
 
 	rows, err := m.DB.Query(stmt)
 	if err != nil {
@@ -67,7 +412,8 @@ func (m *PostModel) GetAll() ([]*Post, error) {
 	for rows.Next() {
 		post := &Post{}
 
-		err = rows.Scan(&post.Id, &post.Title, &post.Content, &post.Created)
+		err = rows.Scan(&post.Id, &post.Title, &post.Content, &post.Excerpt, &post.CoverImage, &post.Summary,
+			&post.WordCount, &post.Reactions, &post.Created)
 		if err != nil {
 			return nil, err
 		}
@@ -78,26 +424,374 @@ func (m *PostModel) GetAll() ([]*Post, error) {
 	return posts, nil
 }
 
-func (m *PostModel) Update(postId int, title, content string) error {
-	stmt := `UPDATE posts
-	SET title = ?, content = ?, created = UTC_TIMESTAMP()
-	WHERE id = ?`
+// GetByUser returns the posts written by a user, newest first, with their
+// tags.
+func (m *PostModel) GetByUser(userId int) ([]*Post, error) {
+	return m.listWithTags("p.user_id = ? AND p.deleted IS NULL", userId)
+}
+
+// GetEverything returns every post, including those in the trash, with
+// their tags. It is used to make backups.
+func (m *PostModel) GetEverything() ([]*Post, error) {
+	return m.listWithTags("TRUE")
+}
 
-	_, err := m.DB.Exec(stmt, title, content, postId)
+// listWithTags returns the posts matching cond, newest first, with their
+// tags. cond may only refer to the posts table, as p.
+func (m *PostModel) listWithTags(cond string, args ...any) ([]*Post, error) {
+	stmt := `SELECT p.id, COALESCE(p.user_id, 0), COALESCE(u.username, ''), COALESCE(p.slug, ''),
+	p.title, p.content, p.status, p.visibility, p.password_hash, COALESCE(p.series_id, 0), p.series_part,
+	p.meta_title, p.meta_description, p.meta_image, p.excerpt, p.cover_image, p.summary, p.word_count,
+	p.created, p.updated, p.deleted
+	FROM posts p LEFT JOIN users u ON u.id = p.user_id
+	WHERE ` + cond + ` ORDER BY p.id DESC`
+
+	rows, err := m.DB.Query(stmt, args...)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	posts := []*Post{}
+	byId := map[int]*Post{}
+
+	for rows.Next() {
+		post := &Post{Tags: []string{}}
+		var deleted sql.NullTime
+
+		err = rows.Scan(&post.Id, &post.UserId, &post.Author, &post.Slug, &post.Title, &post.Content, &post.Status,
+			&post.Visibility, &post.PasswordHash, &post.SeriesId, &post.SeriesPart, &post.Meta.Title,
+			&post.Meta.Description, &post.Meta.Image, &post.Excerpt, &post.CoverImage, &post.Summary, &post.WordCount,
+			&post.Created, &post.Updated, &deleted)
+		if err != nil {
+			return nil, err
+		}
+
+		post.Deleted = deleted.Time
+		posts = append(posts, post)
+		byId[post.Id] = post
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(posts) == 0 {
+		return posts, nil
+	}
+
+	// The tags are restricted by the same condition rather than by a list
+	// of IDs, which could outgrow the placeholder limit in backups.
+	stmt = `SELECT pt.post_id, t.name FROM post_tags pt
+	INNER JOIN tags t ON t.id = pt.tag_id
+	WHERE pt.post_id IN (SELECT p.id FROM posts p WHERE ` + cond + `) ORDER BY t.name`
+
+	tagRows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var postId int
+		var tag string
+
+		err = tagRows.Scan(&postId, &tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if post, ok := byId[postId]; ok {
+			post.Tags = append(post.Tags, tag)
+		}
+	}
+
+	return posts, tagRows.Err()
+}
+
+// List returns the posts that aren't in the trash, optionally only those
+// with the given status, newest first.
+func (m *PostModel) List(status string) ([]*Post, error) {
+	if status == "" {
+		return m.listWithTags("p.deleted IS NULL")
+	}
+
+	return m.listWithTags("p.deleted IS NULL AND p.status = ?", status)
+}
+
+// SetStatus changes the status of the given posts and returns how many
+// were changed.
+func (m *PostModel) SetStatus(ids []int, status string) (int, error) {
+	if !ValidPostStatus(status) {
+		return 0, ErrInvalidStatus
+	}
+
+	return m.updateMany("UPDATE posts SET status = ? WHERE deleted IS NULL AND id IN", ids, status)
+}
+
+// Reassign makes userId the author of the given posts and returns how many
+// were changed.
+func (m *PostModel) Reassign(ids []int, userId int) (int, error) {
+	return m.updateMany("UPDATE posts SET user_id = ? WHERE deleted IS NULL AND id IN", ids, userId)
+}
+
+// updateMany runs stmt, which must end in "IN", for the given post IDs.
+func (m *PostModel) updateMany(stmt string, ids []int, args ...any) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	stmt += " (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	result, err := m.DB.Exec(stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// CountSince returns how many posts, including those in the trash, the user
+// has written since the given time.
+func (m *PostModel) CountSince(userId int, since time.Time) (int, error) {
+	stmt := "SELECT COUNT(*) FROM posts WHERE user_id = ? AND created >= ?"
+
+	var n int
+
+	err := m.DB.QueryRow(stmt, userId, since.UTC()).Scan(&n)
+
+	return n, err
+}
+
+func (m *PostModel) Stats() (*PostStats, error) {
+	stmt := `SELECT
+	COALESCE(SUM(deleted IS NULL AND status = 'published'), 0),
+	COALESCE(SUM(deleted IS NULL AND status IN ('draft', 'changes')), 0),
+	COALESCE(SUM(deleted IS NULL AND status = 'flagged'), 0),
+	COALESCE(SUM(deleted IS NULL AND status = 'review'), 0),
+	COALESCE(SUM(deleted IS NOT NULL), 0),
+	(SELECT COUNT(*) FROM tags)
+	FROM posts`
+
+	stats := &PostStats{}
+
+	err := m.DB.QueryRow(stmt).Scan(&stats.Published, &stats.Drafts, &stats.Flagged, &stats.InReview, &stats.Trashed, &stats.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// Save writes a post from the post form in one transaction. It inserts
+// post if it has no ID and updates the post with its ID otherwise, setting
+// its title, content, status, visibility, series, metadata, card and the
+// users and hashtags its content references. The passphrase is only
+// changed if password isn't empty, and the post only moved in its series
+// if its series or part changed. If series isn't nil, it is started for
+// the post's author and the post added to it instead; a number is added
+// to its slug if another series already uses it. If action isn't empty,
+// it is then taken on the post on behalf of actorId, as Transition does.
+//
+// Save sets the ID and final status of post, the ID and slug of series,
+// and returns the review recorded for action, if any.
+func (m *PostModel) Save(post *Post, series *Series, actorId int, password, action string) (*Review, error) {
+	if !ValidPostStatus(post.Status) {
+		return nil, ErrInvalidStatus
+	}
+	if !ValidPostVisibility(post.Visibility) {
+		return nil, ErrInvalidVisibility
+	}
+
+	var passwordHash []byte
+	if password != "" {
+		var err error
+		passwordHash, err = bcrypt.GenerateFromPassword([]byte(password), m.BcryptCost)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var seriesId, seriesPart int
+
+	if post.Id == 0 {
+		stmt := `INSERT INTO posts (user_id, title, content, status, visibility, password_hash,
+		meta_title, meta_description, meta_image, excerpt, cover_image, summary, word_count, created, updated)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, UTC_TIMESTAMP(), UTC_TIMESTAMP())`
+
+		result, err := tx.Exec(stmt, post.UserId, post.Title, post.Content, post.Status, post.Visibility,
+			string(passwordHash), post.Meta.Title, post.Meta.Description, post.Meta.Image,
+			post.Excerpt, post.CoverImage, Summarize(post.Content), CountWords(post.Content))
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		post.Id = int(id)
+	} else {
+		stmt := `SELECT COALESCE(series_id, 0), series_part FROM posts
+		WHERE id = ? AND deleted IS NULL FOR UPDATE`
+
+		err = tx.QueryRow(stmt, post.Id).Scan(&seriesId, &seriesPart)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrNoRecord
+			}
+			return nil, err
+		}
+
+		stmt = `UPDATE posts SET title = ?, content = ?, status = ?, visibility = ?,
+		password_hash = IF(? = '', password_hash, ?), meta_title = ?, meta_description = ?, meta_image = ?,
+		excerpt = ?, cover_image = ?, summary = ?, word_count = ?, updated = UTC_TIMESTAMP()
+		WHERE id = ?`
+
+		_, err = tx.Exec(stmt, post.Title, post.Content, post.Status, post.Visibility,
+			string(passwordHash), string(passwordHash), post.Meta.Title, post.Meta.Description, post.Meta.Image,
+			post.Excerpt, post.CoverImage, Summarize(post.Content), CountWords(post.Content), post.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if series != nil {
+		series.UserId = post.UserId
+
+		err = insertSeries(tx, series)
+		if err != nil {
+			return nil, err
+		}
+		post.SeriesId = series.Id
+	}
+
+	if post.SeriesId != seriesId || post.SeriesPart != 0 && post.SeriesPart != seriesPart {
+		err = setSeries(tx, post.Id, post.SeriesId, post.SeriesPart)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mentions, hashtags := ParseReferences(post.Content)
+
+	err = setReferences(tx, post.Id, mentions, hashtags)
+	if err != nil {
+		return nil, err
+	}
+
+	var review *Review
+
+	if action != "" {
+		review, err = transition(tx, post.Id, actorId, action, "")
+		if err != nil {
+			return nil, err
+		}
+		post.Status = review.To
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
 }
 
 func (m *PostModel) Delete(id int) error {
-	stmt := "DELETE FROM posts WHERE id = ?"
+	stmt := "UPDATE posts SET deleted = UTC_TIMESTAMP() WHERE id = ? AND deleted IS NULL"
 
-	_, err := m.DB.Exec(stmt, id)
+	return m.execOne(stmt, id)
+}
+
+// GetDeleted returns the posts of a user in the trash, most recently
+// deleted first, or those of every user if userId is 0.
+func (m *PostModel) GetDeleted(userId int) ([]*Post, error) {
+	stmt := `SELECT id, COALESCE(user_id, 0), title, content, created, deleted FROM posts
+	WHERE deleted IS NOT NULL AND (? = 0 OR user_id = ?) ORDER BY deleted DESC`
+
+	rows, err := m.DB.Query(stmt, userId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []*Post{}
+
+	for rows.Next() {
+		post := &Post{}
+
+		err = rows.Scan(&post.Id, &post.UserId, &post.Title, &post.Content, &post.Created, &post.Deleted)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+func (m *PostModel) Restore(id int) error {
+	stmt := "UPDATE posts SET deleted = NULL WHERE id = ? AND deleted IS NOT NULL"
+
+	return m.execOne(stmt, id)
+}
+
+func (m *PostModel) DeletePermanently(id int) error {
+	stmt := "DELETE FROM posts WHERE id = ? AND deleted IS NOT NULL"
+
+	return m.execOne(stmt, id)
+}
+
+// Purge permanently removes posts that have been in the trash for longer
+// than retention and reports how many were removed.
+func (m *PostModel) Purge(retention time.Duration) (int, error) {
+	stmt := "DELETE FROM posts WHERE deleted IS NOT NULL AND deleted < ?"
+
+	result, err := m.DB.Exec(stmt, time.Now().UTC().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// execOne runs stmt and returns ErrNoRecord if it didn't affect any rows.
+func (m *PostModel) execOne(stmt string, args ...any) error {
+	result, err := m.DB.Exec(stmt, args...)
 	if err != nil {
 		return err
 	}
 
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNoRecord
+	}
+
 	return nil
 }
@@ -0,0 +1,369 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestPostModelGet(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	post, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Title, "An old silent pond")
+	assert.Equal(t, post.Created, time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC))
+
+	_, err = m.Get(2)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestPostModelTrash(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	id := newPost(t, &m, 1, "Title", "Content", PostStatusPublished, PostVisibilityPublic)
+
+	assert.NilError(t, m.Delete(id))
+	assert.Equal(t, m.Delete(id), ErrNoRecord)
+
+	_, err := m.Get(id)
+	assert.Equal(t, err, ErrNoRecord)
+
+	posts, err := m.GetAll()
+	assert.NilError(t, err)
+	assert.Equal(t, len(posts), 1)
+
+	deleted, err := m.GetDeleted(0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(deleted), 1)
+	assert.Equal(t, deleted[0].Id, id)
+
+	n, err := m.Purge(time.Hour)
+	assert.NilError(t, err)
+	assert.Equal(t, n, 0)
+
+	assert.NilError(t, m.Restore(id))
+	assert.Equal(t, m.Restore(id), ErrNoRecord)
+	assert.Equal(t, m.DeletePermanently(id), ErrNoRecord)
+
+	assert.NilError(t, m.Delete(id))
+	assert.NilError(t, m.DeletePermanently(id))
+
+	_, err = m.Get(id)
+	assert.Equal(t, err, ErrNoRecord)
+}
+
+func TestPostModelSetStatus(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	id := newPost(t, &m, 1, "Title", "Content", PostStatusPublished, PostVisibilityPublic)
+
+	n, err := m.SetStatus([]int{1, id, 99}, PostStatusDraft)
+	assert.NilError(t, err)
+	assert.Equal(t, n, 2)
+
+	_, err = m.SetStatus([]int{1}, "secret")
+	assert.Equal(t, err, ErrInvalidStatus)
+
+	posts, err := m.GetAll()
+	assert.NilError(t, err)
+	assert.Equal(t, len(posts), 0)
+
+	drafts, err := m.List(PostStatusDraft)
+	assert.NilError(t, err)
+	assert.Equal(t, len(drafts), 2)
+
+	n, err = m.Reassign([]int{id}, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, n, 0)
+
+	n, err = m.CountSince(1, time.Now().Add(-time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, n, 1)
+
+	_, err = m.SetStatus([]int{id}, PostStatusFlagged)
+	assert.NilError(t, err)
+
+	stats, err := m.Stats()
+	assert.NilError(t, err)
+	assert.Equal(t, *stats, PostStats{Drafts: 1, Flagged: 1})
+}
+
+func TestPostModelVisibility(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db, BcryptCost: 4}
+
+	unlisted := newPost(t, &m, 1, "Unlisted", "Content", PostStatusPublished, PostVisibilityUnlisted)
+
+	// Posts can be made password-protected after they were published.
+	post := &Post{UserId: 1, Title: "Protected", Content: "Content", Status: PostStatusPublished, Visibility: PostVisibilityPublic}
+	_, err := m.Save(post, nil, 1, "", "")
+	assert.NilError(t, err)
+	protected := post.Id
+
+	post.Visibility = PostVisibilityProtected
+	_, err = m.Save(post, nil, 1, "open sesame", "")
+	assert.NilError(t, err)
+
+	_, err = m.Save(&Post{UserId: 1, Title: "Title", Status: PostStatusPublished, Visibility: "hidden"}, nil, 1, "", "")
+	assert.Equal(t, err, ErrInvalidVisibility)
+
+	posts, err := m.GetAll()
+	assert.NilError(t, err)
+	assert.Equal(t, len(posts), 1)
+
+	post, err = m.Get(unlisted)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Visibility, PostVisibilityUnlisted)
+
+	post, err = m.Get(protected)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Visibility, PostVisibilityProtected)
+	assert.Equal(t, post.CheckPassword("open sesame"), true)
+	assert.Equal(t, post.CheckPassword("wrong"), false)
+}
+
+func TestPostModelUpsertKeepsPassword(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db, BcryptCost: 4}
+
+	post := &Post{UserId: 1, Title: "Protected", Content: "Content", Status: PostStatusPublished, Visibility: PostVisibilityProtected}
+	_, err := m.Save(post, nil, 1, "open sesame", "")
+	assert.NilError(t, err)
+	id := post.Id
+
+	post, err = m.Get(id)
+	assert.NilError(t, err)
+
+	post.Title = "Restored"
+	post.PasswordHash = nil
+	assert.NilError(t, m.Upsert(post))
+
+	post, err = m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Title, "Restored")
+	assert.Equal(t, post.CheckPassword("open sesame"), true)
+}
+
+func TestPostModelImport(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	created := time.Date(2015, 6, 1, 9, 30, 0, 0, time.UTC)
+
+	id, err := m.Import(&Post{
+		UserId:  1,
+		Slug:    "hello-world",
+		Title:   "Hello World",
+		Content: "Welcome",
+		Tags:    []string{"intro", "meta"},
+		Created: created,
+	})
+	assert.NilError(t, err)
+
+	post, err := m.GetBySlug("hello-world")
+	assert.NilError(t, err)
+	assert.Equal(t, post.Id, id)
+	assert.Equal(t, post.Author, "alice")
+	assert.Equal(t, post.Created, created)
+	assert.Equal(t, len(post.Tags), 2)
+
+	_, err = m.Import(&Post{Slug: "hello-world", Title: "Again", Created: created})
+	assert.Equal(t, err, ErrDuplicateSlug)
+}
+
+func TestNextStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		action string
+		want   string
+		err    error
+	}{
+		{"Submit draft", PostStatusDraft, ReviewSubmit, PostStatusInReview, nil},
+		{"Resubmit", PostStatusChangesRequested, ReviewSubmit, PostStatusInReview, nil},
+		{"Withdraw", PostStatusInReview, ReviewWithdraw, PostStatusDraft, nil},
+		{"Approve", PostStatusInReview, ReviewApprove, PostStatusPublished, nil},
+		{"Request changes", PostStatusInReview, ReviewRequestChanges, PostStatusChangesRequested, nil},
+		{"Approve draft", PostStatusDraft, ReviewApprove, "", ErrInvalidTransition},
+		{"Submit published", PostStatusPublished, ReviewSubmit, "", ErrInvalidTransition},
+		{"Resubmit edited", PostStatusPublished, ReviewResubmit, PostStatusInReview, nil},
+		{"Resubmit draft", PostStatusDraft, ReviewResubmit, "", ErrInvalidTransition},
+		{"Publish draft", PostStatusDraft, ReviewPublish, PostStatusPublished, nil},
+		{"Publish flagged", PostStatusFlagged, ReviewPublish, PostStatusPublished, nil},
+		{"Publish published", PostStatusPublished, ReviewPublish, "", ErrInvalidTransition},
+		{"Unpublish", PostStatusPublished, ReviewUnpublish, PostStatusDraft, nil},
+		{"Unpublish draft", PostStatusDraft, ReviewUnpublish, "", ErrInvalidTransition},
+		{"Release flagged", PostStatusFlagged, ReviewRelease, PostStatusPublished, nil},
+		{"Release draft", PostStatusDraft, ReviewRelease, "", ErrInvalidTransition},
+		{"Release flagged to review", PostStatusFlagged, ReviewReleaseToReview, PostStatusInReview, nil},
+		{"Release published to review", PostStatusPublished, ReviewReleaseToReview, "", ErrInvalidTransition},
+		{"Unknown action", PostStatusInReview, "merge", "", ErrInvalidTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := NextStatus(tt.status, tt.action)
+			assert.Equal(t, status, tt.want)
+			assert.Equal(t, err, tt.err)
+		})
+	}
+}
+
+func TestPostModelTransition(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	id := newPost(t, &m, 1, "Title", "Content", PostStatusDraft, PostVisibilityPublic)
+
+	_, err := m.Transition(id, 1, ReviewApprove, "")
+	assert.Equal(t, err, ErrInvalidTransition)
+
+	_, err = m.Transition(id, 1, ReviewSubmit, "")
+	assert.NilError(t, err)
+
+	review, err := m.Transition(id, 1, ReviewRequestChanges, "Needs a better title")
+	assert.NilError(t, err)
+	assert.Equal(t, review.From, PostStatusInReview)
+	assert.Equal(t, review.To, PostStatusChangesRequested)
+
+	_, err = m.Transition(99, 1, ReviewSubmit, "")
+	assert.Equal(t, err, ErrNoRecord)
+
+	post, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Status, PostStatusChangesRequested)
+
+	reviews, err := m.Reviews(id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(reviews), 2)
+	assert.Equal(t, reviews[1].Actor, "alice")
+	assert.Equal(t, reviews[1].Note, "Needs a better title")
+
+	reviews[1].Note = "Restored"
+	assert.NilError(t, m.UpsertReview(reviews[1]))
+
+	reviews, err = m.Reviews(0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(reviews), 2)
+	assert.Equal(t, reviews[1].Note, "Restored")
+}
+
+func TestPostModelArchive(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	_, err := m.Import(&Post{UserId: 1, Title: "Leap day", Created: time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)})
+	assert.NilError(t, err)
+	_, err = m.Import(&Post{UserId: 1, Title: "Hidden", Visibility: PostVisibilityUnlisted,
+		Created: time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)})
+	assert.NilError(t, err)
+
+	months, err := m.Archive()
+	assert.NilError(t, err)
+	assert.Equal(t, len(months), 2)
+	assert.Equal(t, *months[0], ArchiveMonth{Year: 2024, Month: time.March, Posts: 1,
+		Updated: time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC)})
+	assert.Equal(t, months[1].Posts, 1)
+
+	posts, err := m.GetBetween(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.NilError(t, err)
+	assert.Equal(t, len(posts), 1)
+	assert.Equal(t, posts[0].Title, "Leap day")
+
+	// Editing a post leaves it in the month it was created in.
+	post, err := m.Get(1)
+	assert.NilError(t, err)
+	post.Content = "Plop"
+	_, err = m.Save(post, nil, 1, "", "")
+	assert.NilError(t, err)
+
+	post, err = m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Created, time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC))
+	assert.Equal(t, post.Updated.After(post.Created), true)
+
+	months, err = m.Archive()
+	assert.NilError(t, err)
+	assert.Equal(t, months[0].Month, time.March)
+	assert.Equal(t, months[0].Updated, post.Updated)
+}
+
+func TestPostModelSave(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db, BcryptCost: 4}
+
+	post := &Post{
+		UserId:     1,
+		Title:      "Saved",
+		Content:    "Hello @alice #golang",
+		Status:     PostStatusDraft,
+		Visibility: PostVisibilityProtected,
+		Meta:       PostMeta{Title: "Shared title"},
+		Excerpt:    "In short",
+	}
+
+	review, err := m.Save(post, nil, 1, "open sesame", ReviewSubmit)
+	assert.NilError(t, err)
+	assert.Equal(t, review.To, PostStatusInReview)
+	assert.Equal(t, review.ActorId, 1)
+	assert.Equal(t, post.Status, PostStatusInReview)
+
+	saved, err := m.Get(post.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, saved.Status, PostStatusInReview)
+	assert.Equal(t, saved.Meta.Title, "Shared title")
+	assert.Equal(t, saved.Excerpt, "In short")
+	assert.Equal(t, saved.CheckPassword("open sesame"), true)
+	assert.Equal(t, len(saved.Mentions), 1)
+
+	// A failing review action leaves the post as it was.
+	post.Content = "Rewritten"
+	_, err = m.Save(post, nil, 1, "", ReviewSubmit)
+	assert.Equal(t, err, ErrInvalidTransition)
+
+	saved, err = m.Get(post.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, saved.Content, "Hello @alice #golang")
+
+	_, err = m.Save(post, nil, 1, "", "")
+	assert.NilError(t, err)
+
+	saved, err = m.Get(post.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, saved.Content, "Rewritten")
+	assert.Equal(t, saved.CheckPassword("open sesame"), true)
+	assert.Equal(t, len(saved.Mentions), 0)
+
+	post.Meta = PostMeta{Title: "Why small changes win", Description: "Notes on shipping", Image: "https://cdn.example.com/cover.png"}
+	_, err = m.Save(post, nil, 1, "", "")
+	assert.NilError(t, err)
+
+	saved, err = m.Get(post.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, saved.Meta, post.Meta)
+
+	post.Meta = PostMeta{}
+	_, err = m.Save(post, nil, 1, "", "")
+	assert.NilError(t, err)
+
+	saved, err = m.Get(post.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, saved.Meta, PostMeta{})
+
+	_, err = m.Save(&Post{Id: 99, Title: "Missing", Status: PostStatusPublished, Visibility: PostVisibilityPublic}, nil, 1, "", "")
+	assert.Equal(t, err, ErrNoRecord)
+}
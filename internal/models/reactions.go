@@ -0,0 +1,205 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Reaction kinds readers can respond to a post with, in the order they
+// are shown. Each reader can give a post one reaction of each kind.
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionLaugh = "laugh"
+	ReactionWow   = "wow"
+	ReactionSad   = "sad"
+)
+
+func ReactionKinds() []string {
+	return []string{ReactionLike, ReactionLove, ReactionLaugh, ReactionWow, ReactionSad}
+}
+
+func ValidReactionKind(kind string) bool {
+	switch kind {
+	case ReactionLike, ReactionLove, ReactionLaugh, ReactionWow, ReactionSad:
+		return true
+	}
+	return false
+}
+
+// Reaction is one reader's reaction to a post.
+type Reaction struct {
+	PostId  int
+	UserId  int
+	Kind    string
+	Created time.Time
+}
+
+// ReactionCount is how many readers reacted to a post with a kind of
+// reaction, and whether the reader asking is one of them.
+type ReactionCount struct {
+	Kind    string
+	Count   int
+	Reacted bool
+}
+
+// React adds userId's reaction of the given kind to a post, or takes it
+// back if the user already reacted that way, and reports whether the user
+// has the reaction now. The post's total in reaction_count is kept up to
+// date so listings can show and sort by it without counting.
+func (m *PostModel) React(postId, userId int, kind string) (bool, error) {
+	if !ValidReactionKind(kind) {
+		return false, ErrInvalidReaction
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var id int
+
+	err = tx.QueryRow("SELECT id FROM posts WHERE id = ? AND deleted IS NULL FOR UPDATE", postId).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrNoRecord
+		}
+		return false, err
+	}
+
+	result, err := tx.Exec("DELETE FROM post_reactions WHERE post_id = ? AND user_id = ? AND kind = ?", postId, userId, kind)
+	if err != nil {
+		return false, err
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	delta := -1
+
+	if removed == 0 {
+		delta = 1
+
+		stmt := `INSERT INTO post_reactions (post_id, user_id, kind, created)
+		VALUES(?, ?, ?, UTC_TIMESTAMP())`
+
+		_, err = tx.Exec(stmt, postId, userId, kind)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE posts SET reaction_count = reaction_count + ? WHERE id = ?", delta, postId)
+	if err != nil {
+		return false, err
+	}
+
+	return removed == 0, tx.Commit()
+}
+
+// Reactions returns how many readers reacted to a post with each kind of
+// reaction, in the order of ReactionKinds, marking the kinds userId
+// reacted with. userId is 0 for anonymous readers.
+func (m *PostModel) Reactions(postId, userId int) ([]*ReactionCount, error) {
+	stmt := `SELECT kind, COUNT(*), COALESCE(SUM(user_id = ?), 0) FROM post_reactions
+	WHERE post_id = ? GROUP BY kind`
+
+	rows, err := m.DB.Query(stmt, userId, postId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byKind := map[string]*ReactionCount{}
+
+	for rows.Next() {
+		count := &ReactionCount{}
+		var mine int
+
+		err = rows.Scan(&count.Kind, &count.Count, &mine)
+		if err != nil {
+			return nil, err
+		}
+
+		count.Reacted = mine > 0
+		byKind[count.Kind] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	counts := []*ReactionCount{}
+	for _, kind := range ReactionKinds() {
+		count, ok := byKind[kind]
+		if !ok {
+			count = &ReactionCount{Kind: kind}
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+// AllReactions returns every reaction to every post, for backups.
+func (m *PostModel) AllReactions() ([]*Reaction, error) {
+	stmt := `SELECT post_id, user_id, kind, created FROM post_reactions ORDER BY post_id, user_id, kind`
+
+	rows, err := m.DB.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := []*Reaction{}
+
+	for rows.Next() {
+		reaction := &Reaction{}
+
+		err = rows.Scan(&reaction.PostId, &reaction.UserId, &reaction.Kind, &reaction.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		reactions = append(reactions, reaction)
+	}
+
+	return reactions, rows.Err()
+}
+
+// UpsertReaction adds a reaction, or updates when it was given if it
+// already exists, and recounts the post's reactions. It is used to restore
+// backups.
+func (m *PostModel) UpsertReaction(reaction *Reaction) error {
+	if !ValidReactionKind(reaction.Kind) {
+		return ErrInvalidReaction
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO post_reactions (post_id, user_id, kind, created) VALUES(?, ?, ?, ?) AS new
+	ON DUPLICATE KEY UPDATE created = new.created`
+
+	_, err = tx.Exec(stmt, reaction.PostId, reaction.UserId, reaction.Kind, reaction.Created.UTC())
+	if err != nil {
+		return err
+	}
+
+	stmt = `UPDATE posts SET reaction_count = (SELECT COUNT(*) FROM post_reactions WHERE post_id = ?)
+	WHERE id = ?`
+
+	_, err = tx.Exec(stmt, reaction.PostId, reaction.PostId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestPostModelReact(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	reacted, err := m.React(1, 1, ReactionLove)
+	assert.NilError(t, err)
+	assert.Equal(t, reacted, true)
+
+	_, err = m.React(1, 1, ReactionLike)
+	assert.NilError(t, err)
+
+	counts, err := m.Reactions(1, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(counts), len(ReactionKinds()))
+	assert.Equal(t, *counts[0], ReactionCount{Kind: ReactionLike, Count: 1, Reacted: true})
+	assert.Equal(t, *counts[1], ReactionCount{Kind: ReactionLove, Count: 1, Reacted: true})
+	assert.Equal(t, *counts[2], ReactionCount{Kind: ReactionLaugh})
+
+	counts, err = m.Reactions(1, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, counts[1].Reacted, false)
+
+	posts, err := m.Latest(SortMostReacted)
+	assert.NilError(t, err)
+	assert.Equal(t, posts[0].Reactions, 2)
+
+	reacted, err = m.React(1, 1, ReactionLike)
+	assert.NilError(t, err)
+	assert.Equal(t, reacted, false)
+
+	post, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Reactions, 1)
+
+	_, err = m.React(1, 1, "angry")
+	assert.Equal(t, err, ErrInvalidReaction)
+
+	_, err = m.React(99, 1, ReactionLike)
+	assert.Equal(t, err, ErrNoRecord)
+
+	_, err = m.Latest("random")
+	assert.Equal(t, err, ErrInvalidSort)
+}
+
+func TestPostModelUpsertReaction(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	created := time.Date(2024, 3, 18, 9, 0, 0, 0, time.UTC)
+
+	for range 2 {
+		assert.NilError(t, m.UpsertReaction(&Reaction{PostId: 1, UserId: 1, Kind: ReactionWow, Created: created}))
+	}
+	assert.Equal(t, m.UpsertReaction(&Reaction{PostId: 1, UserId: 1, Kind: "angry"}), ErrInvalidReaction)
+
+	reactions, err := m.AllReactions()
+	assert.NilError(t, err)
+	assert.Equal(t, len(reactions), 1)
+	assert.Equal(t, *reactions[0], Reaction{PostId: 1, UserId: 1, Kind: ReactionWow, Created: created})
+
+	post, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, post.Reactions, 1)
+}
@@ -0,0 +1,353 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/anxxuj/microblog/internal/validator"
+	"github.com/go-sql-driver/mysql"
+)
+
+// maxHashtag is the length of the post_hashtags.tag column.
+const maxHashtag = 50
+
+// referenceRX matches @mentions and #hashtags together with the character
+// before them, which must not be part of a word, so that email addresses
+// and URL fragments aren't taken for references.
+var referenceRX = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_/@#&])([@#])([\p{L}\p{N}_]+)`)
+
+// Reference is an @mention or #hashtag in a post's content. Start and End
+// are the byte offsets of the whole token, including the @ or #, and Name
+// is the username or hashtag without it. Hashtags are lower case.
+type Reference struct {
+	Start   int
+	End     int
+	Mention bool
+	Name    string
+}
+
+// FindReferences returns the references in content in the order they
+// appear. Mentions must be valid usernames, whether or not the user
+// exists, and hashtags must contain a letter and be at most 50 characters
+// long; other tokens are left as text.
+func FindReferences(content string) []Reference {
+	refs := []Reference{}
+
+	for _, m := range referenceRX.FindAllStringSubmatchIndex(content, -1) {
+		ref := Reference{Start: m[2], End: m[5], Mention: content[m[2]] == '@', Name: content[m[4]:m[5]]}
+
+		if ref.Mention {
+			if !validator.Matches(ref.Name, validator.UsernameRX) {
+				continue
+			}
+		} else {
+			if utf8.RuneCountInString(ref.Name) > maxHashtag || strings.IndexFunc(ref.Name, unicode.IsLetter) < 0 {
+				continue
+			}
+			ref.Name = strings.ToLower(ref.Name)
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs
+}
+
+// ParseReferences returns the usernames mentioned in content and its
+// hashtags, each once, in the order they first appear.
+func ParseReferences(content string) (mentions, hashtags []string) {
+	mentions, hashtags = []string{}, []string{}
+
+	for _, ref := range FindReferences(content) {
+		if ref.Mention {
+			if !slices.ContainsFunc(mentions, func(name string) bool { return strings.EqualFold(name, ref.Name) }) {
+				mentions = append(mentions, ref.Name)
+			}
+		} else if !slices.Contains(hashtags, ref.Name) {
+			hashtags = append(hashtags, ref.Name)
+		}
+	}
+
+	return mentions, hashtags
+}
+
+// SetReferences replaces the users a post mentions and its hashtags.
+// Mentions of users that don't exist are left out. Users who were already
+// mentioned keep their place in ClaimMentions, so that they are only
+// notified once.
+func (m *PostModel) SetReferences(postId int, mentions, hashtags []string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = setReferences(tx, postId, mentions, hashtags)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setReferences is SetReferences within tx.
+func setReferences(tx *sql.Tx, postId int, mentions, hashtags []string) error {
+	ids := []int{}
+
+	for _, username := range mentions {
+		var id int
+
+		err := tx.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		ids = append(ids, id)
+	}
+
+	before, err := mentionedIds(tx, postId)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range before {
+		if !slices.Contains(ids, id) {
+			_, err = tx.Exec("DELETE FROM post_mentions WHERE post_id = ? AND user_id = ?", postId, id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, id := range ids {
+		_, err = tx.Exec("INSERT IGNORE INTO post_mentions (post_id, user_id) VALUES(?, ?)", postId, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM post_hashtags WHERE post_id = ?", postId)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range hashtags {
+		_, err = tx.Exec("INSERT IGNORE INTO post_hashtags (post_id, tag) VALUES(?, ?)", postId, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClaimMentions returns a notification for each user a post mentions who
+// hasn't been notified yet, and marks them as notified. Mentions in posts
+// that others can't read, because they aren't published, are private or
+// are in the trash, are left pending until they can.
+func (m *PostModel) ClaimMentions(postId int) ([]*Notification, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt := `SELECT pm.user_id, COALESCE(p.user_id, 0) FROM post_mentions pm
+	INNER JOIN posts p ON p.id = pm.post_id
+	WHERE pm.post_id = ? AND NOT pm.notified
+	AND p.status = ? AND p.visibility <> ? AND p.deleted IS NULL
+	ORDER BY pm.user_id FOR UPDATE`
+
+	rows, err := tx.Query(stmt, postId, PostStatusPublished, PostVisibilityPrivate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*Notification{}
+
+	for rows.Next() {
+		n := &Notification{Kind: NotificationMention, PostId: postId}
+
+		err = rows.Scan(&n.UserId, &n.ActorId)
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, n)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(notifications) == 0 {
+		return notifications, nil
+	}
+
+	_, err = tx.Exec("UPDATE post_mentions SET notified = TRUE WHERE post_id = ? AND NOT notified", postId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// Mention is a user mentioned in a post, and whether they were notified.
+type Mention struct {
+	PostId   int
+	UserId   int
+	Notified bool
+}
+
+// AllMentions returns every user mentioned in every post, for backups.
+func (m *PostModel) AllMentions() ([]*Mention, error) {
+	rows, err := m.DB.Query("SELECT post_id, user_id, notified FROM post_mentions ORDER BY post_id, user_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mentions := []*Mention{}
+
+	for rows.Next() {
+		mention := &Mention{}
+
+		err = rows.Scan(&mention.PostId, &mention.UserId, &mention.Notified)
+		if err != nil {
+			return nil, err
+		}
+
+		mentions = append(mentions, mention)
+	}
+
+	return mentions, rows.Err()
+}
+
+// UpsertMention adds a mention, or updates whether its user was notified
+// if it already exists. It is used to restore backups.
+func (m *PostModel) UpsertMention(mention *Mention) error {
+	stmt := `INSERT INTO post_mentions (post_id, user_id, notified) VALUES(?, ?, ?) AS new
+	ON DUPLICATE KEY UPDATE notified = new.notified`
+
+	_, err := m.DB.Exec(stmt, mention.PostId, mention.UserId, mention.Notified)
+	if err != nil {
+		var mySQLError *mysql.MySQLError
+		if errors.As(err, &mySQLError) && mySQLError.Number == 1452 {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	return nil
+}
+
+func mentionedIds(tx *sql.Tx, postId int) ([]int, error) {
+	rows, err := tx.Query("SELECT user_id FROM post_mentions WHERE post_id = ? ORDER BY user_id", postId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+
+	for rows.Next() {
+		var id int
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// mentions returns the usernames of the users a post mentions.
+func (m *PostModel) mentions(postId int) ([]string, error) {
+	stmt := `SELECT u.username FROM users u
+	INNER JOIN post_mentions pm ON pm.user_id = u.id
+	WHERE pm.post_id = ? ORDER BY u.username`
+
+	rows, err := m.DB.Query(stmt, postId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usernames := []string{}
+
+	for rows.Next() {
+		var username string
+
+		err = rows.Scan(&username)
+		if err != nil {
+			return nil, err
+		}
+
+		usernames = append(usernames, username)
+	}
+
+	return usernames, rows.Err()
+}
+
+// LatestByHashtag returns the published, public posts with a hashtag for
+// listings, newest first.
+func (m *PostModel) LatestByHashtag(tag string) ([]*Post, error) {
+	return m.listCards(`p.deleted IS NULL AND p.status = 'published' AND p.visibility = 'public'
+	AND p.id IN (SELECT post_id FROM post_hashtags WHERE tag = ?)`, "p.id DESC", strings.ToLower(tag))
+}
+
+// Hashtag is a hashtag used by published, public posts and when any of
+// them was last changed.
+type Hashtag struct {
+	Tag     string
+	Updated time.Time
+}
+
+// Hashtags returns the hashtags used by published, public posts in
+// alphabetical order.
+func (m *PostModel) Hashtags() ([]*Hashtag, error) {
+	stmt := `SELECT h.tag, MAX(p.updated) FROM post_hashtags h
+	INNER JOIN posts p ON p.id = h.post_id
+	WHERE p.deleted IS NULL AND p.status = 'published' AND p.visibility = 'public'
+	GROUP BY h.tag ORDER BY h.tag`
+
+	rows, err := m.DB.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashtags := []*Hashtag{}
+
+	for rows.Next() {
+		hashtag := &Hashtag{}
+
+		err = rows.Scan(&hashtag.Tag, &hashtag.Updated)
+		if err != nil {
+			return nil, err
+		}
+
+		hashtags = append(hashtags, hashtag)
+	}
+
+	return hashtags, rows.Err()
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestFindReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Reference
+	}{
+		{"mention", "Thanks @alice!", []Reference{{Start: 7, End: 13, Mention: true, Name: "alice"}}},
+		{"hashtag", "#Go is fun", []Reference{{Start: 0, End: 3, Name: "go"}}},
+		{"unicode hashtag", "俳句 #松尾芭蕉", []Reference{{Start: 7, End: 20, Name: "松尾芭蕉"}}},
+		{"email", "mail bob@example.com", []Reference{}},
+		{"url fragment", "see example.com/#intro and https://example.com/@alice", []Reference{}},
+		{"short username", "hi @bob", []Reference{}},
+		{"number", "issue #123", []Reference{}},
+		{"adjacent", "@alice#go", []Reference{{Start: 0, End: 6, Mention: true, Name: "alice"}}},
+		{"in parentheses", "(#Go)", []Reference{{Start: 1, End: 4, Name: "go"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := FindReferences(tt.content)
+			assert.Equal(t, len(refs), len(tt.want))
+			for i := range refs {
+				assert.Equal(t, refs[i], tt.want[i])
+			}
+		})
+	}
+}
+
+func TestParseReferences(t *testing.T) {
+	mentions, hashtags := ParseReferences("@alice @Alice @carol_ #go #Go #frogs @nobody1")
+
+	assert.Equal(t, len(mentions), 3)
+	assert.Equal(t, mentions[0], "alice")
+	assert.Equal(t, mentions[1], "carol_")
+	assert.Equal(t, mentions[2], "nobody1")
+
+	assert.Equal(t, len(hashtags), 2)
+	assert.Equal(t, hashtags[0], "go")
+	assert.Equal(t, hashtags[1], "frogs")
+}
+
+func TestPostModelSetReferences(t *testing.T) {
+	db := newTestDB(t)
+
+	users := UserModel{DB: db, BcryptCost: 4}
+	m := PostModel{DB: db}
+
+	assert.NilError(t, users.Insert("dave", "dave@example.com", "pa$$word"))
+	dave, err := users.GetByUsername("dave")
+	assert.NilError(t, err)
+
+	err = m.SetReferences(1, []string{"dave", "nobody"}, []string{"frogs", "haiku"})
+	assert.NilError(t, err)
+
+	post, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(post.Mentions), 1)
+	assert.Equal(t, post.Mentions[0], "dave")
+
+	notifications, err := m.ClaimMentions(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(notifications), 1)
+	assert.Equal(t, notifications[0].UserId, dave.Id)
+	assert.Equal(t, notifications[0].ActorId, 1)
+
+	// Mentioning the same user again doesn't notify them again.
+	err = m.SetReferences(1, []string{"alice", "dave"}, []string{"frogs"})
+	assert.NilError(t, err)
+
+	notifications, err = m.ClaimMentions(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(notifications), 1)
+	assert.Equal(t, notifications[0].UserId, 1)
+
+	notifications, err = m.ClaimMentions(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(notifications), 0)
+
+	posts, err := m.LatestByHashtag("Frogs")
+	assert.NilError(t, err)
+	assert.Equal(t, len(posts), 1)
+	assert.Equal(t, posts[0].Title, "An old silent pond")
+
+	posts, err = m.LatestByHashtag("haiku")
+	assert.NilError(t, err)
+	assert.Equal(t, len(posts), 0)
+
+	hashtags, err := m.Hashtags()
+	assert.NilError(t, err)
+	assert.Equal(t, len(hashtags), 1)
+	assert.Equal(t, *hashtags[0], Hashtag{Tag: "frogs", Updated: time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC)})
+}
+
+func TestPostModelClaimMentionsWhenPublished(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	id := newPost(t, &m, 1, "Draft", "Hello @alice", PostStatusDraft, PostVisibilityPublic)
+	assert.NilError(t, m.SetReferences(id, []string{"alice"}, nil))
+
+	notifications, err := m.ClaimMentions(id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(notifications), 0)
+
+	_, err = m.Transition(id, 1, ReviewPublish, "")
+	assert.NilError(t, err)
+
+	notifications, err = m.ClaimMentions(id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(notifications), 1)
+}
+
+func TestPostModelUpsertMention(t *testing.T) {
+	db := newTestDB(t)
+
+	m := PostModel{DB: db}
+
+	assert.NilError(t, m.SetReferences(1, []string{"alice"}, nil))
+	assert.NilError(t, m.UpsertMention(&Mention{PostId: 1, UserId: 1, Notified: true}))
+	assert.Equal(t, m.UpsertMention(&Mention{PostId: 1, UserId: 99}), ErrNoRecord)
+
+	mentions, err := m.AllMentions()
+	assert.NilError(t, err)
+	assert.Equal(t, len(mentions), 1)
+	assert.Equal(t, *mentions[0], Mention{PostId: 1, UserId: 1, Notified: true})
+
+	notifications, err := m.ClaimMentions(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(notifications), 0)
+}
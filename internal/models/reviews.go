@@ -0,0 +1,203 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Review actions move a post through the editorial workflow:
+//
+//	draft, changes --submit--> review --approve--> published
+//	                           review --request_changes--> changes
+//	                           review --withdraw--> draft
+//	     published --resubmit--> review
+//	       flagged --release--> published
+//	       flagged --release_to_review--> review
+//
+// Authors submit and withdraw their posts, and resubmit published posts
+// they edit; reviewers approve them or ask for changes. Admins publish
+// and unpublish posts in any status, and moderators release posts held
+// back by the spam filter, to the review queue if their author's posts
+// need review.
+const (
+	ReviewSubmit          = "submit"
+	ReviewWithdraw        = "withdraw"
+	ReviewResubmit        = "resubmit"
+	ReviewApprove         = "approve"
+	ReviewRequestChanges  = "request_changes"
+	ReviewPublish         = "publish"
+	ReviewUnpublish       = "unpublish"
+	ReviewRelease         = "release"
+	ReviewReleaseToReview = "release_to_review"
+)
+
+type reviewTransition struct {
+	from     []string
+	to       string
+	reviewer bool
+}
+
+var reviewTransitions = map[string]reviewTransition{
+	ReviewSubmit:         {from: []string{PostStatusDraft, PostStatusChangesRequested}, to: PostStatusInReview},
+	ReviewWithdraw:       {from: []string{PostStatusInReview}, to: PostStatusDraft},
+	ReviewResubmit:       {from: []string{PostStatusPublished}, to: PostStatusInReview},
+	ReviewApprove:        {from: []string{PostStatusInReview}, to: PostStatusPublished, reviewer: true},
+	ReviewRequestChanges: {from: []string{PostStatusInReview}, to: PostStatusChangesRequested, reviewer: true},
+	ReviewPublish: {from: []string{PostStatusDraft, PostStatusInReview, PostStatusChangesRequested, PostStatusFlagged},
+		to: PostStatusPublished, reviewer: true},
+	ReviewUnpublish: {from: []string{PostStatusPublished, PostStatusInReview, PostStatusChangesRequested, PostStatusFlagged},
+		to: PostStatusDraft, reviewer: true},
+	ReviewRelease:         {from: []string{PostStatusFlagged}, to: PostStatusPublished, reviewer: true},
+	ReviewReleaseToReview: {from: []string{PostStatusFlagged}, to: PostStatusInReview, reviewer: true},
+}
+
+// NextStatus returns the status a post with the given status moves to when
+// action is taken, or ErrInvalidTransition if the action isn't possible in
+// that status.
+func NextStatus(status, action string) (string, error) {
+	t, ok := reviewTransitions[action]
+	if !ok {
+		return "", ErrInvalidTransition
+	}
+
+	for _, from := range t.from {
+		if from == status {
+			return t.to, nil
+		}
+	}
+
+	return "", ErrInvalidTransition
+}
+
+// ReviewerAction reports whether action is taken by a reviewer rather than
+// the post's author.
+func ReviewerAction(action string) bool {
+	return reviewTransitions[action].reviewer
+}
+
+// Review is a transition of a post in the editorial workflow. Note is the
+// reviewer's or author's comment, if any.
+type Review struct {
+	Id      int
+	PostId  int
+	ActorId int
+	Actor   string
+	Action  string
+	From    string
+	To      string
+	Note    string
+	Created time.Time
+}
+
+// Transition takes action on a post on behalf of actorId and records it in
+// the post's review history. It returns the recorded review, or
+// ErrInvalidTransition if the post's current status doesn't allow the
+// action. Whether the actor may take the action is up to the caller.
+func (m *PostModel) Transition(postId, actorId int, action, note string) (*Review, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	review, err := transition(tx, postId, actorId, action, note)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// transition is Transition within tx.
+func transition(tx *sql.Tx, postId, actorId int, action, note string) (*Review, error) {
+	review := &Review{PostId: postId, ActorId: actorId, Action: action, Note: note}
+
+	stmt := "SELECT status FROM posts WHERE id = ? AND deleted IS NULL FOR UPDATE"
+
+	err := tx.QueryRow(stmt, postId).Scan(&review.From)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	review.To, err = NextStatus(review.From, action)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE posts SET status = ? WHERE id = ?", review.To, postId)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Created = time.Now().UTC().Truncate(time.Second)
+
+	stmt = `INSERT INTO post_reviews (post_id, actor_id, action, from_status, to_status, note, created)
+	VALUES(?, NULLIF(?, 0), ?, ?, ?, ?, ?)`
+
+	result, err := tx.Exec(stmt, postId, actorId, action, review.From, review.To, note, review.Created)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	review.Id = int(id)
+
+	return review, nil
+}
+
+// Reviews returns the review history of a post, oldest first, or that of
+// every post if postId is 0.
+func (m *PostModel) Reviews(postId int) ([]*Review, error) {
+	stmt := `SELECT r.id, r.post_id, COALESCE(r.actor_id, 0), COALESCE(u.username, ''),
+	r.action, r.from_status, r.to_status, r.note, r.created
+	FROM post_reviews r LEFT JOIN users u ON u.id = r.actor_id
+	WHERE ? = 0 OR r.post_id = ? ORDER BY r.id`
+
+	rows, err := m.DB.Query(stmt, postId, postId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []*Review{}
+
+	for rows.Next() {
+		review := &Review{}
+
+		err = rows.Scan(&review.Id, &review.PostId, &review.ActorId, &review.Actor,
+			&review.Action, &review.From, &review.To, &review.Note, &review.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+// UpsertReview inserts review with its ID, or overwrites the review with
+// that ID if it already exists. It is used to restore backups.
+func (m *PostModel) UpsertReview(review *Review) error {
+	stmt := `INSERT INTO post_reviews (id, post_id, actor_id, action, from_status, to_status, note, created)
+	VALUES(?, ?, NULLIF(?, 0), ?, ?, ?, ?, ?) AS new
+	ON DUPLICATE KEY UPDATE post_id = new.post_id, actor_id = new.actor_id, action = new.action,
+	from_status = new.from_status, to_status = new.to_status, note = new.note, created = new.created`
+
+	_, err := m.DB.Exec(stmt, review.Id, review.PostId, review.ActorId, review.Action, review.From, review.To,
+		review.Note, review.Created.UTC())
+
+	return err
+}
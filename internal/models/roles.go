@@ -0,0 +1,54 @@
+package models
+
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Permission names something a user may be allowed to do. Users are
+// granted permissions through their role.
+type Permission string
+
+const (
+	PermissionAdmin    Permission = "admin"
+	PermissionModerate Permission = "moderate"
+	// PermissionViewPrivate allows reading other users' private and
+	// password-protected posts.
+	PermissionViewPrivate Permission = "view_private"
+	// PermissionReview allows approving posts submitted for review and
+	// publishing without review.
+	PermissionReview Permission = "review"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleUser:   {},
+	RoleEditor: {PermissionReview},
+	RoleAdmin:  {PermissionAdmin, PermissionModerate, PermissionViewPrivate, PermissionReview},
+}
+
+// Roles returns the names of all roles, from least to most privileged.
+func Roles() []string {
+	return []string{RoleUser, RoleEditor, RoleAdmin}
+}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can reports whether the user's role grants permission p. Disabled users
+// can't do anything.
+func (u *User) Can(p Permission) bool {
+	if u == nil || u.Disabled {
+		return false
+	}
+
+	for _, granted := range rolePermissions[u.Role] {
+		if granted == p {
+			return true
+		}
+	}
+
+	return false
+}
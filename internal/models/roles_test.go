@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestUserCan(t *testing.T) {
+	tests := []struct {
+		name string
+		user *User
+		want bool
+	}{
+		{"Admin", &User{Role: RoleAdmin}, true},
+		{"User", &User{Role: RoleUser}, false},
+		{"Disabled admin", &User{Role: RoleAdmin, Disabled: true}, false},
+		{"Unknown role", &User{Role: "owner"}, false},
+		{"Nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.user.Can(PermissionAdmin), tt.want)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type SeriesModelInterface interface {
+	Get(id int) (*Series, error)
+	GetBySlug(slug string) (*Series, error)
+	GetByUser(userId int) ([]*Series, error)
+	GetAll() ([]*Series, error)
+	Upsert(series *Series) error
+}
+
+// Series is an ordered collection of posts, such as a tutorial split into
+// several parts. Posts join a series, or start a new one, with
+// PostModel.Save.
+type Series struct {
+	Id      int
+	UserId  int
+	Author  string
+	Slug    string
+	Title   string
+	Created time.Time
+}
+
+type SeriesModel struct {
+	DB *sql.DB
+}
+
+func (m *SeriesModel) Get(id int) (*Series, error) {
+	return m.getWhere("s.id = ?", id)
+}
+
+func (m *SeriesModel) GetBySlug(slug string) (*Series, error) {
+	return m.getWhere("s.slug = ?", slug)
+}
+
+func (m *SeriesModel) getWhere(cond string, args ...any) (*Series, error) {
+	stmt := `SELECT s.id, COALESCE(s.user_id, 0), COALESCE(u.username, ''), s.slug, s.title, s.created
+	FROM series s LEFT JOIN users u ON u.id = s.user_id
+	WHERE ` + cond
+
+	series := &Series{}
+
+	err := m.DB.QueryRow(stmt, args...).Scan(&series.Id, &series.UserId, &series.Author, &series.Slug,
+		&series.Title, &series.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// GetByUser returns the series started by a user, ordered by title.
+func (m *SeriesModel) GetByUser(userId int) ([]*Series, error) {
+	stmt := `SELECT id, user_id, slug, title, created FROM series
+	WHERE user_id = ? ORDER BY title, id`
+
+	rows, err := m.DB.Query(stmt, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := []*Series{}
+
+	for rows.Next() {
+		series := &Series{}
+
+		err = rows.Scan(&series.Id, &series.UserId, &series.Slug, &series.Title, &series.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, series)
+	}
+
+	return all, rows.Err()
+}
+
+// GetAll returns every series, for backups.
+func (m *SeriesModel) GetAll() ([]*Series, error) {
+	stmt := `SELECT id, COALESCE(user_id, 0), slug, title, created FROM series ORDER BY id`
+
+	rows, err := m.DB.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := []*Series{}
+
+	for rows.Next() {
+		series := &Series{}
+
+		err = rows.Scan(&series.Id, &series.UserId, &series.Slug, &series.Title, &series.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, series)
+	}
+
+	return all, rows.Err()
+}
+
+// Upsert inserts a series with the given ID, or overwrites the series with
+// that ID if it exists.
+func (m *SeriesModel) Upsert(series *Series) error {
+	stmt := `INSERT INTO series (id, user_id, slug, title, created)
+	VALUES(?, NULLIF(?, 0), ?, ?, ?) AS new
+	ON DUPLICATE KEY UPDATE user_id = new.user_id, slug = new.slug, title = new.title, created = new.created`
+
+	_, err := m.DB.Exec(stmt, series.Id, series.UserId, series.Slug, series.Title, series.Created.UTC())
+	if err != nil {
+		var mySQLError *mysql.MySQLError
+		if errors.As(err, &mySQLError) && mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "series_uc_slug") {
+			return ErrDuplicateSlug
+		}
+		return err
+	}
+
+	return nil
+}
+
+// insertSeries starts series in tx and sets its ID. If another series
+// already uses its slug, a number is added to the slug, starting at 2.
+func insertSeries(tx *sql.Tx, series *Series) error {
+	stmt := `INSERT INTO series (user_id, slug, title, created)
+	VALUES(?, ?, ?, UTC_TIMESTAMP())`
+
+	base := series.Slug
+
+	for i := 2; ; i++ {
+		result, err := tx.Exec(stmt, series.UserId, series.Slug, series.Title)
+		if err != nil {
+			var mySQLError *mysql.MySQLError
+			if errors.As(err, &mySQLError) && mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "series_uc_slug") {
+				series.Slug = fmt.Sprintf("%s-%d", base, i)
+				continue
+			}
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		series.Id = int(id)
+
+		return nil
+	}
+}
+
+// setSeries makes a post that tx has locked the given part of a series.
+// Part 0 adds the post after the last part, and series 0 takes the post
+// out of its series. Parts don't need to be consecutive; posts are ordered
+// by part and then by ID.
+func setSeries(tx *sql.Tx, postId, seriesId, part int) error {
+	if seriesId != 0 && part == 0 {
+		stmt := "SELECT COALESCE(MAX(series_part), 0) + 1 FROM posts WHERE series_id = ? AND id <> ?"
+
+		err := tx.QueryRow(stmt, seriesId, postId).Scan(&part)
+		if err != nil {
+			return err
+		}
+	}
+
+	if seriesId == 0 {
+		part = 0
+	}
+
+	_, err := tx.Exec("UPDATE posts SET series_id = NULLIF(?, 0), series_part = ? WHERE id = ?", seriesId, part, postId)
+
+	return err
+}
+
+// GetBySeries returns the posts of a series that aren't in the trash, in
+// order, whatever their status and visibility.
+func (m *PostModel) GetBySeries(seriesId int) ([]*Post, error) {
+	stmt := `SELECT id, COALESCE(user_id, 0), title, status, visibility, series_id, series_part, created
+	FROM posts WHERE series_id = ? AND deleted IS NULL ORDER BY series_part, id`
+
+	rows, err := m.DB.Query(stmt, seriesId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []*Post{}
+
+	for rows.Next() {
+		post := &Post{}
+
+		err = rows.Scan(&post.Id, &post.UserId, &post.Title, &post.Status, &post.Visibility,
+			&post.SeriesId, &post.SeriesPart, &post.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestSeriesModel(t *testing.T) {
+	db := newTestDB(t)
+
+	m := SeriesModel{DB: db}
+	posts := PostModel{DB: db}
+
+	post, err := posts.Get(1)
+	assert.NilError(t, err)
+	_, err = posts.Save(post, &Series{Slug: "learning-go", Title: "Learning Go"}, 1, "", "")
+	assert.NilError(t, err)
+
+	series, err := m.GetBySlug("learning-go")
+	assert.NilError(t, err)
+	assert.Equal(t, series.Id, post.SeriesId)
+	assert.Equal(t, series.Author, "alice")
+
+	all, err := m.GetByUser(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(all), 1)
+
+	_, err = m.Get(99)
+	assert.Equal(t, err, ErrNoRecord)
+
+	restored := &Series{Id: 5, UserId: 1, Slug: "restored", Title: "Restored", Created: series.Created}
+	assert.NilError(t, m.Upsert(restored))
+	restored.Title = "Restored again"
+	assert.NilError(t, m.Upsert(restored))
+	assert.Equal(t, m.Upsert(&Series{Id: 6, Slug: "restored", Title: "Clash", Created: series.Created}), ErrDuplicateSlug)
+
+	all, err = m.GetAll()
+	assert.NilError(t, err)
+	assert.Equal(t, len(all), 2)
+	assert.Equal(t, all[1].Title, "Restored again")
+}
+
+func TestPostModelSaveSeries(t *testing.T) {
+	db := newTestDB(t)
+
+	series := SeriesModel{DB: db}
+	m := PostModel{DB: db}
+
+	first, err := m.Get(1)
+	assert.NilError(t, err)
+
+	learning := &Series{Slug: "learning-go", Title: "Learning Go"}
+	_, err = m.Save(first, learning, 1, "", "")
+	assert.NilError(t, err)
+	assert.Equal(t, first.SeriesId, learning.Id)
+	assert.Equal(t, learning.UserId, 1)
+
+	second := &Post{UserId: 1, Title: "Second", Content: "Content", Status: PostStatusPublished,
+		Visibility: PostVisibilityPublic, SeriesId: learning.Id}
+	_, err = m.Save(second, nil, 1, "", "")
+	assert.NilError(t, err)
+
+	parts, err := m.GetBySeries(learning.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(parts), 2)
+	assert.Equal(t, parts[0].Id, 1)
+	assert.Equal(t, parts[1].SeriesPart, 2)
+
+	// Moving the second post to the front.
+	second.SeriesPart = 1
+	_, err = m.Save(second, nil, 1, "", "")
+	assert.NilError(t, err)
+	first.SeriesPart = 2
+	_, err = m.Save(first, nil, 1, "", "")
+	assert.NilError(t, err)
+
+	post, err := m.Get(second.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, post.SeriesId, learning.Id)
+	assert.Equal(t, post.SeriesPart, 1)
+
+	second.SeriesId, second.SeriesPart = 0, 0
+	_, err = m.Save(second, nil, 1, "", "")
+	assert.NilError(t, err)
+
+	parts, err = m.GetBySeries(learning.Id)
+	assert.NilError(t, err)
+	assert.Equal(t, len(parts), 1)
+	assert.Equal(t, parts[0].Id, 1)
+
+	// A new series whose slug is taken gets a number added to it.
+	again := &Series{Slug: "learning-go", Title: "Learning Go again"}
+	_, err = m.Save(second, again, 1, "", "")
+	assert.NilError(t, err)
+	assert.Equal(t, again.Slug, "learning-go-2")
+	assert.Equal(t, second.SeriesId, again.Id)
+
+	// A new series isn't started if the post can't be saved.
+	_, err = m.Save(&Post{Id: 99, Title: "Missing", Status: PostStatusPublished, Visibility: PostVisibilityPublic},
+		&Series{Slug: "orphan", Title: "Orphan"}, 1, "", "")
+	assert.Equal(t, err, ErrNoRecord)
+
+	_, err = series.GetBySlug("orphan")
+	assert.Equal(t, err, ErrNoRecord)
+}
@@ -0,0 +1,182 @@
+CREATE TABLE users (
+    id INT PRIMARY KEY AUTO_INCREMENT NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    password_hash CHAR(60) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'user',
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    language VARCHAR(10) NOT NULL DEFAULT '',
+    time_zone VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+ALTER TABLE users ADD CONSTRAINT users_uc_username UNIQUE (username);
+ALTER TABLE users ADD CONSTRAINT users_uc_email UNIQUE (email);
+
+CREATE TABLE series (
+    id INT PRIMARY KEY AUTO_INCREMENT NOT NULL,
+    user_id INT NULL,
+    slug VARCHAR(255) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT series_fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+ALTER TABLE series ADD CONSTRAINT series_uc_slug UNIQUE (slug);
+
+CREATE TABLE posts (
+    id INT PRIMARY KEY AUTO_INCREMENT NOT NULL,
+    user_id INT NULL,
+    slug VARCHAR(255) NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'published',
+    visibility VARCHAR(20) NOT NULL DEFAULT 'public',
+    password_hash VARCHAR(60) NOT NULL DEFAULT '',
+    series_id INT NULL,
+    series_part INT NOT NULL DEFAULT 0,
+    meta_title VARCHAR(140) NOT NULL DEFAULT '',
+    meta_description VARCHAR(300) NOT NULL DEFAULT '',
+    meta_image VARCHAR(2048) NOT NULL DEFAULT '',
+    excerpt VARCHAR(300) NOT NULL DEFAULT '',
+    cover_image VARCHAR(2048) NOT NULL DEFAULT '',
+    summary VARCHAR(300) NOT NULL DEFAULT '',
+    word_count INT NOT NULL DEFAULT 0,
+    reaction_count INT NOT NULL DEFAULT 0,
+    created DATETIME NOT NULL,
+    updated DATETIME NOT NULL,
+    deleted DATETIME NULL,
+    CONSTRAINT posts_fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL,
+    CONSTRAINT posts_fk_series FOREIGN KEY (series_id) REFERENCES series (id) ON DELETE SET NULL
+);
+
+ALTER TABLE posts ADD CONSTRAINT posts_uc_slug UNIQUE (slug);
+CREATE INDEX posts_deleted_idx ON posts (deleted);
+CREATE INDEX posts_status_idx ON posts (status);
+
+CREATE TABLE tags (
+    id INT PRIMARY KEY AUTO_INCREMENT NOT NULL,
+    name VARCHAR(255) NOT NULL
+);
+
+ALTER TABLE tags ADD CONSTRAINT tags_uc_name UNIQUE (name);
+
+CREATE TABLE post_tags (
+    post_id INT NOT NULL,
+    tag_id INT NOT NULL,
+    PRIMARY KEY (post_id, tag_id),
+    CONSTRAINT post_tags_fk_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    CONSTRAINT post_tags_fk_tag FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+
+CREATE TABLE post_reviews (
+    id INT PRIMARY KEY AUTO_INCREMENT NOT NULL,
+    post_id INT NOT NULL,
+    actor_id INT NULL,
+    action VARCHAR(20) NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    note TEXT NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT post_reviews_fk_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    CONSTRAINT post_reviews_fk_actor FOREIGN KEY (actor_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX post_reviews_post_idx ON post_reviews (post_id);
+
+CREATE TABLE post_reactions (
+    post_id INT NOT NULL,
+    user_id INT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    created DATETIME NOT NULL,
+    PRIMARY KEY (post_id, user_id, kind),
+    CONSTRAINT post_reactions_fk_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    CONSTRAINT post_reactions_fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE follows (
+    follower_id INT NOT NULL,
+    followed_id INT NOT NULL,
+    created DATETIME NOT NULL,
+    PRIMARY KEY (follower_id, followed_id),
+    CONSTRAINT follows_fk_follower FOREIGN KEY (follower_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT follows_fk_followed FOREIGN KEY (followed_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX follows_followed_idx ON follows (followed_id);
+
+CREATE TABLE post_mentions (
+    post_id INT NOT NULL,
+    user_id INT NOT NULL,
+    notified BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (post_id, user_id),
+    CONSTRAINT post_mentions_fk_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    CONSTRAINT post_mentions_fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX post_mentions_user_idx ON post_mentions (user_id);
+
+CREATE TABLE post_hashtags (
+    post_id INT NOT NULL,
+    tag VARCHAR(50) NOT NULL,
+    PRIMARY KEY (post_id, tag),
+    CONSTRAINT post_hashtags_fk_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX post_hashtags_tag_idx ON post_hashtags (tag);
+
+CREATE TABLE notifications (
+    id INT PRIMARY KEY AUTO_INCREMENT NOT NULL,
+    user_id INT NOT NULL,
+    actor_id INT NULL,
+    kind VARCHAR(20) NOT NULL,
+    post_id INT NULL,
+    detail VARCHAR(50) NOT NULL DEFAULT '',
+    created DATETIME NOT NULL,
+    read_at DATETIME NULL,
+    CONSTRAINT notifications_fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT notifications_fk_actor FOREIGN KEY (actor_id) REFERENCES users (id) ON DELETE SET NULL,
+    CONSTRAINT notifications_fk_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX notifications_user_idx ON notifications (user_id, read_at);
+
+CREATE TABLE notification_mutes (
+    user_id INT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    PRIMARY KEY (user_id, kind),
+    CONSTRAINT notification_mutes_fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE audit_log (
+    id INT PRIMARY KEY AUTO_INCREMENT NOT NULL,
+    created DATETIME(6) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    actor_id INT NULL,
+    ip VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL,
+    target_type VARCHAR(20) NULL,
+    target_id INT NULL,
+    before_data JSON NULL,
+    after_data JSON NULL
+);
+
+CREATE INDEX audit_log_created_idx ON audit_log (created);
+CREATE INDEX audit_log_action_idx ON audit_log (action, created);
+CREATE INDEX audit_log_actor_idx ON audit_log (actor_id, created);
+
+INSERT INTO users (username, email, password_hash) VALUES (
+    'alice',
+    'alice@example.com',
+    '$2a$12$QJADtpQeiNkcjPnWupVz8OS7lcSkruWrqHmh8bZyYsGKRCTlWCIgm'
+);
+
+INSERT INTO posts (user_id, slug, title, content, summary, word_count, created, updated) VALUES (
+    1,
+    'old-pond',
+    'An old silent pond',
+    'A frog jumps into the pond',
+    'A frog jumps into the pond',
+    6,
+    '2024-03-17 10:15:00',
+    '2024-03-17 10:15:00'
+);
@@ -0,0 +1,13 @@
+DROP TABLE post_hashtags;
+DROP TABLE post_mentions;
+DROP TABLE notification_mutes;
+DROP TABLE notifications;
+DROP TABLE follows;
+DROP TABLE audit_log;
+DROP TABLE post_reactions;
+DROP TABLE post_reviews;
+DROP TABLE post_tags;
+DROP TABLE tags;
+DROP TABLE posts;
+DROP TABLE series;
+DROP TABLE users;
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+)
+
+// newTestDB connects to the disposable database named by MICROBLOG_TEST_DSN,
+// creates the tables from testdata/setup.sql and drops them again when the
+// test finishes. The test is skipped if MICROBLOG_TEST_DSN isn't set. The
+// DSN must enable parseTime and multiStatements, e.g.
+//
+//	test_web:pass@/test_microblog?parseTime=true&multiStatements=true
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("MICROBLOG_TEST_DSN")
+	if dsn == "" {
+		t.Skip("models: MICROBLOG_TEST_DSN not set; skipping database test")
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script, err := os.ReadFile("./testdata/setup.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(string(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		defer db.Close()
+
+		script, err := os.ReadFile("./testdata/teardown.sql")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = db.Exec(string(script))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	return db
+}
+
+// newPost saves a post by userId with the given title, content, status
+// and visibility and returns its ID.
+func newPost(t *testing.T, m *PostModel, userId int, title, content, status, visibility string) int {
+	t.Helper()
+
+	post := &Post{UserId: userId, Title: title, Content: content, Status: status, Visibility: visibility}
+
+	_, err := m.Save(post, nil, userId, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return post.Id
+}
@@ -1,27 +1,67 @@
 package models
 
 import (
+	"crypto/rand"
 	"database/sql"
 	"errors"
+	"strconv"
 	"strings"
 
 	"github.com/go-sql-driver/mysql"
 	"golang.org/x/crypto/bcrypt"
 )
 
+type UserModelInterface interface {
+	Insert(username, email, password string) error
+	Authenticate(username, password string) (int, error)
+	Exists(id int) (bool, error)
+	Get(id int) (*User, error)
+	GetByUsername(username string) (*User, error)
+	GetByEmail(email string) (*User, error)
+	GetAll() ([]*User, error)
+	Search(query string) ([]*User, error)
+	Upsert(user *User) error
+	SetDisabled(id int, disabled bool) error
+	SetRole(id int, role string) error
+	SetPassword(id int, password string) error
+	SetPreferences(id int, language, timeZone string) error
+	Stats() (*UserStats, error)
+	Follow(followerId, followedId int) error
+	Unfollow(followerId, followedId int) error
+	IsFollowing(followerId, followedId int) (bool, error)
+	FollowCounts(userId int) (*FollowCounts, error)
+	Followers(userId int) ([]*User, error)
+	Following(userId int) ([]*User, error)
+	AllFollows() ([]*Follow, error)
+	UpsertFollow(follow *Follow) error
+}
+
 type User struct {
 	Id           int
 	Username     string
 	Email        string
 	PasswordHash []byte
+	Role         string
+	Disabled     bool
+	// Language and TimeZone are the user's preferred language tag and IANA
+	// time zone name. Empty means the browser's language and UTC.
+	Language string
+	TimeZone string
+}
+
+type UserStats struct {
+	Total    int
+	Admins   int
+	Disabled int
 }
 
 type UserModel struct {
-	DB *sql.DB
+	DB         *sql.DB
+	BcryptCost int
 }
 
 func (m *UserModel) Insert(username, email, password string) error {
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), m.BcryptCost)
 	if err != nil {
 		return err
 	}
@@ -41,6 +81,7 @@ func (m *UserModel) Insert(username, email, password string) error {
 				return err
 			}
 		}
+		return err
 	}
 
 	return nil
@@ -49,10 +90,11 @@ func (m *UserModel) Insert(username, email, password string) error {
 func (m *UserModel) Authenticate(username, password string) (int, error) {
 	var id int
 	var passwordHash []byte
+	var disabled bool
 
-	stmt := "SELECT id, password_hash FROM users WHERE username = ?"
+	stmt := "SELECT id, password_hash, disabled FROM users WHERE username = ?"
 
-	err := m.DB.QueryRow(stmt, username).Scan(&id, &passwordHash)
+	err := m.DB.QueryRow(stmt, username).Scan(&id, &passwordHash, &disabled)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, ErrInvalidCredentials
@@ -70,6 +112,10 @@ func (m *UserModel) Authenticate(username, password string) (int, error) {
 		}
 	}
 
+	if disabled {
+		return 0, ErrAccountDisabled
+	}
+
 	return id, nil
 }
 
@@ -81,3 +127,175 @@ func (m *UserModel) Exists(id int) (bool, error) {
 	err := m.DB.QueryRow(stmt, id).Scan(&exists)
 	return exists, err
 }
+
+func (m *UserModel) Get(id int) (*User, error) {
+	return m.getWhere("id = ?", id)
+}
+
+func (m *UserModel) GetByUsername(username string) (*User, error) {
+	return m.getWhere("username = ?", username)
+}
+
+func (m *UserModel) GetByEmail(email string) (*User, error) {
+	return m.getWhere("email = ?", email)
+}
+
+func (m *UserModel) getWhere(cond string, args ...any) (*User, error) {
+	stmt := "SELECT id, username, email, password_hash, role, disabled, language, time_zone FROM users WHERE " + cond
+
+	user := &User{}
+
+	err := m.DB.QueryRow(stmt, args...).Scan(&user.Id, &user.Username, &user.Email, &user.PasswordHash, &user.Role, &user.Disabled, &user.Language, &user.TimeZone)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		} else {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+func (m *UserModel) GetAll() ([]*User, error) {
+	return m.list("TRUE", 0)
+}
+
+// Search returns up to 100 users whose username or email contains query.
+func (m *UserModel) Search(query string) ([]*User, error) {
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query) + "%"
+
+	return m.list("username LIKE ? OR email LIKE ?", 100, pattern, pattern)
+}
+
+func (m *UserModel) list(cond string, limit int, args ...any) ([]*User, error) {
+	stmt := "SELECT id, username, email, password_hash, role, disabled, language, time_zone FROM users WHERE " + cond + " ORDER BY id"
+
+	if limit > 0 {
+		stmt += " LIMIT " + strconv.Itoa(limit)
+	}
+
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*User{}
+
+	for rows.Next() {
+		user := &User{}
+
+		err = rows.Scan(&user.Id, &user.Username, &user.Email, &user.PasswordHash, &user.Role, &user.Disabled, &user.Language, &user.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (m *UserModel) SetDisabled(id int, disabled bool) error {
+	return m.update(id, "UPDATE users SET disabled = ? WHERE id = ?", disabled, id)
+}
+
+func (m *UserModel) SetRole(id int, role string) error {
+	if !ValidRole(role) {
+		return ErrInvalidRole
+	}
+
+	return m.update(id, "UPDATE users SET role = ? WHERE id = ?", role, id)
+}
+
+func (m *UserModel) SetPassword(id int, password string) error {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), m.BcryptCost)
+	if err != nil {
+		return err
+	}
+
+	return m.update(id, "UPDATE users SET password_hash = ? WHERE id = ?", string(passwordHash), id)
+}
+
+func (m *UserModel) SetPreferences(id int, language, timeZone string) error {
+	return m.update(id, "UPDATE users SET language = ?, time_zone = ? WHERE id = ?", language, timeZone, id)
+}
+
+func (m *UserModel) Stats() (*UserStats, error) {
+	stmt := `SELECT COUNT(*), COALESCE(SUM(role = ?), 0), COALESCE(SUM(disabled), 0) FROM users`
+
+	stats := &UserStats{}
+
+	err := m.DB.QueryRow(stmt, RoleAdmin).Scan(&stats.Total, &stats.Admins, &stats.Disabled)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// update runs stmt, which updates the user with the given ID, and returns
+// ErrNoRecord if there is no such user.
+func (m *UserModel) update(id int, stmt string, args ...any) error {
+	result, err := m.DB.Exec(stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// MySQL doesn't count rows that already had the new values as
+	// affected, so check that the user exists before reporting it missing.
+	if n == 0 {
+		exists, err := m.Exists(id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNoRecord
+		}
+	}
+
+	return nil
+}
+
+// Upsert inserts user with its ID, or updates the user with that ID if it
+// already exists. It is used to restore backups. Without a password hash
+// an existing user keeps their password and a new user gets a random one,
+// so they need to have it reset before they can log in.
+func (m *UserModel) Upsert(user *User) error {
+	passwordHash := user.PasswordHash
+	keepPassword := len(passwordHash) == 0
+
+	if keepPassword {
+		random := make([]byte, 32)
+		_, err := rand.Read(random)
+		if err != nil {
+			return err
+		}
+
+		passwordHash, err = bcrypt.GenerateFromPassword(random, bcrypt.MinCost)
+		if err != nil {
+			return err
+		}
+	}
+
+	role := user.Role
+	if role == "" {
+		role = RoleUser
+	}
+
+	stmt := `INSERT INTO users (id, username, email, password_hash, role, disabled, language, time_zone)
+	VALUES(?, ?, ?, ?, ?, ?, ?, ?) AS new
+	ON DUPLICATE KEY UPDATE username = new.username, email = new.email,
+	password_hash = IF(?, users.password_hash, new.password_hash),
+	role = new.role, disabled = new.disabled, language = new.language, time_zone = new.time_zone`
+
+	_, err := m.DB.Exec(stmt, user.Id, user.Username, user.Email, string(passwordHash), role, user.Disabled,
+		user.Language, user.TimeZone, keepPassword)
+	return err
+}
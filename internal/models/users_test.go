@@ -0,0 +1,124 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestUserModelExists(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID int
+		want   bool
+	}{
+		{"Valid ID", 1, true},
+		{"Zero ID", 0, false},
+		{"Non-existent ID", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			m := UserModel{DB: db}
+
+			exists, err := m.Exists(tt.userID)
+
+			assert.Equal(t, exists, tt.want)
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestUserModelInsert(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		email    string
+		wantErr  error
+	}{
+		{"Valid", "bobby", "bob@example.com", nil},
+		{"Duplicate username", "alice", "other@example.com", ErrDuplicateUsername},
+		{"Duplicate email", "carol", "alice@example.com", ErrDuplicateEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			m := UserModel{DB: db, BcryptCost: 4}
+
+			err := m.Insert(tt.username, tt.email, "pa$$word")
+
+			assert.Equal(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUserModelAuthenticate(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantID   int
+		wantErr  error
+	}{
+		{"Valid", "alice", "pa$$word", 1, nil},
+		{"Wrong password", "alice", "wrong", 0, ErrInvalidCredentials},
+		{"Unknown user", "nobody", "pa$$word", 0, ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			m := UserModel{DB: db}
+
+			id, err := m.Authenticate(tt.username, tt.password)
+
+			assert.Equal(t, id, tt.wantID)
+			assert.Equal(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUserModelAdmin(t *testing.T) {
+	db := newTestDB(t)
+
+	m := UserModel{DB: db, BcryptCost: 4}
+
+	user, err := m.Get(1)
+	assert.NilError(t, err)
+	assert.Equal(t, user.Role, RoleUser)
+	assert.Equal(t, user.Disabled, false)
+
+	assert.NilError(t, m.SetRole(1, RoleAdmin))
+	assert.Equal(t, m.SetRole(1, "owner"), ErrInvalidRole)
+	assert.Equal(t, m.SetRole(2, RoleAdmin), ErrNoRecord)
+
+	assert.NilError(t, m.SetDisabled(1, true))
+	assert.NilError(t, m.SetDisabled(1, true))
+
+	_, err = m.Authenticate("alice", "pa$$word")
+	assert.Equal(t, err, ErrAccountDisabled)
+
+	assert.NilError(t, m.SetDisabled(1, false))
+	assert.NilError(t, m.SetPassword(1, "new password"))
+
+	id, err := m.Authenticate("alice", "new password")
+	assert.NilError(t, err)
+	assert.Equal(t, id, 1)
+
+	users, err := m.Search("ALICE@")
+	assert.NilError(t, err)
+	assert.Equal(t, len(users), 1)
+
+	users, err = m.Search("%")
+	assert.NilError(t, err)
+	assert.Equal(t, len(users), 0)
+
+	stats, err := m.Stats()
+	assert.NilError(t, err)
+	assert.Equal(t, *stats, UserStats{Total: 1, Admins: 1})
+}
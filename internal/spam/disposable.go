@@ -0,0 +1,49 @@
+package spam
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed disposable_domains.txt
+var disposableList string
+
+var disposableDomains = parseDomains(disposableList)
+
+func parseDomains(list string) map[string]bool {
+	domains := map[string]bool{}
+
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			domains[strings.ToLower(line)] = true
+		}
+	}
+
+	return domains
+}
+
+// DisposableEmail reports whether email belongs to a known disposable mail
+// provider, including any of its subdomains.
+func DisposableEmail(email string) bool {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+
+	domain := strings.ToLower(strings.TrimSuffix(email[at+1:], "."))
+
+	for domain != "" {
+		if disposableDomains[domain] {
+			return true
+		}
+
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			break
+		}
+		domain = parent
+	}
+
+	return false
+}
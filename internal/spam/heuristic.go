@@ -0,0 +1,85 @@
+package spam
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultKeywords are the phrases Heuristic looks for when it has no
+// keywords of its own.
+var DefaultKeywords = []string{
+	"buy followers",
+	"casino",
+	"cialis",
+	"crypto giveaway",
+	"free money",
+	"make money fast",
+	"payday loan",
+	"viagra",
+	"weight loss pills",
+	"work from home",
+}
+
+var linkRX = regexp.MustCompile(`(?i)\bhttps?://\S+|\bwww\.\S+`)
+
+// Heuristic is a Classifier that runs locally. Every keyword found adds
+// 0.5 to the score, and having too many links, or too many links for the
+// amount of text, adds 1 each. Content scoring at least Threshold is spam.
+// The zero value uses DefaultKeywords and sensible limits.
+type Heuristic struct {
+	Keywords []string
+	// MaxLinks is the number of links allowed regardless of length.
+	MaxLinks int
+	// MaxLinkDensity is the highest allowed ratio of links to words.
+	MaxLinkDensity float64
+	Threshold      float64
+}
+
+func (h *Heuristic) Classify(c Content) (Verdict, error) {
+	keywords := h.Keywords
+	if keywords == nil {
+		keywords = DefaultKeywords
+	}
+	maxLinks := h.MaxLinks
+	if maxLinks == 0 {
+		maxLinks = 10
+	}
+	maxLinkDensity := h.MaxLinkDensity
+	if maxLinkDensity == 0 {
+		maxLinkDensity = 0.1
+	}
+	threshold := h.Threshold
+	if threshold == 0 {
+		threshold = 1
+	}
+
+	text := c.Title + "\n" + c.Body
+	lower := strings.ToLower(text)
+
+	var v Verdict
+
+	for _, keyword := range keywords {
+		if strings.Contains(lower, strings.ToLower(keyword)) {
+			v.Score += 0.5
+			v.Reasons = append(v.Reasons, fmt.Sprintf("contains %q", keyword))
+		}
+	}
+
+	links := len(linkRX.FindAllString(text, -1))
+	words := len(strings.Fields(linkRX.ReplaceAllString(text, "")))
+
+	if links > maxLinks {
+		v.Score++
+		v.Reasons = append(v.Reasons, fmt.Sprintf("%d links", links))
+	}
+
+	if links > 1 && float64(links) > maxLinkDensity*float64(words) {
+		v.Score++
+		v.Reasons = append(v.Reasons, fmt.Sprintf("%d links in %d words", links, words))
+	}
+
+	v.Spam = v.Score >= threshold
+
+	return v, nil
+}
@@ -0,0 +1,66 @@
+package spam
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestHeuristic(t *testing.T) {
+	tests := []struct {
+		name    string
+		content Content
+		want    bool
+	}{
+		{"Plain", Content{"Hello", "An old silent pond. A frog jumps into the pond, splash! Silence again."}, false},
+		{"One keyword", Content{"My casino trip", "We went to the casino in Monaco and lost a little."}, false},
+		{"Two keywords", Content{"Free money", "Win free money at our online CASINO today."}, true},
+		{"One link", Content{"Link", "See https://example.com"}, false},
+		{"Link dense", Content{"Deals", "https://a.example http://b.example www.c.example great deals"}, true},
+		{"Many links", Content{"Links", strings.Repeat("Some interesting reading at https://example.com/page for everyone. ", 11)}, true},
+	}
+
+	h := &Heuristic{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := h.Classify(tt.content)
+			assert.NilError(t, err)
+			assert.Equal(t, v.Spam, tt.want)
+			assert.Equal(t, len(v.Reasons) > 0, v.Score > 0)
+		})
+	}
+}
+
+func TestHeuristicKeywords(t *testing.T) {
+	h := &Heuristic{Keywords: []string{"lottery"}, Threshold: 0.5}
+
+	v, err := h.Classify(Content{Body: "You won the Lottery"})
+	assert.NilError(t, err)
+	assert.Equal(t, v.Spam, true)
+
+	v, err = h.Classify(Content{Body: "Visit our casino"})
+	assert.NilError(t, err)
+	assert.Equal(t, v.Spam, false)
+}
+
+func TestDisposableEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"alice@example.com", false},
+		{"bot@mailinator.com", true},
+		{"bot@MAILINATOR.COM", true},
+		{"bot@eu.mailinator.com", true},
+		{"bot@notmailinator.com", false},
+		{"invalid", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			assert.Equal(t, DisposableEmail(tt.email), tt.want)
+		})
+	}
+}
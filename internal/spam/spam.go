@@ -0,0 +1,24 @@
+// Package spam decides whether submitted content looks like spam and
+// whether email addresses belong to disposable mail providers.
+package spam
+
+// Content is what a classifier looks at.
+type Content struct {
+	Title string
+	Body  string
+}
+
+// Verdict is a classifier's decision. Reasons explain a positive verdict
+// to moderators.
+type Verdict struct {
+	Spam    bool
+	Score   float64
+	Reasons []string
+}
+
+// Classifier is implemented by spam classifiers. Implementations that call
+// out to a remote service should return an error rather than a negative
+// verdict when they can't reach it.
+type Classifier interface {
+	Classify(c Content) (Verdict, error)
+}
@@ -1,8 +1,11 @@
 package validator
 
 import (
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -57,3 +60,41 @@ func Matches(value string, rx *regexp.Regexp) bool {
 func EqualTo(a, b string) bool {
 	return a == b
 }
+
+func PermittedValue[T comparable](value T, permittedValues ...T) bool {
+	for i := range permittedValues {
+		if value == permittedValues[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPositiveInt reports whether value is a whole number greater than zero.
+func IsPositiveInt(value string) bool {
+	n, err := strconv.Atoi(value)
+	return err == nil && n > 0
+}
+
+// IsHTTPURL reports whether value is an absolute http or https URL.
+func IsHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// IsDate reports whether value is a date in the YYYY-MM-DD format.
+func IsDate(value string) bool {
+	_, err := time.Parse(time.DateOnly, value)
+	return err == nil
+}
+
+// IsTimeZone reports whether value names a time zone in the IANA database.
+// "Local" is rejected since it depends on the server.
+func IsTimeZone(value string) bool {
+	if value == "" || value == "Local" {
+		return false
+	}
+
+	_, err := time.LoadLocation(value)
+	return err == nil
+}
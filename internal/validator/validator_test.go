@@ -0,0 +1,84 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/anxxuj/microblog/internal/assert"
+)
+
+func TestMatchesUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		want     bool
+	}{
+		{"alice", true},
+		{"al1ce_", true},
+		{"abc", false},
+		{"1alice", false},
+		{"alice!", false},
+		{"a23456789012345678901234567890", true},
+		{"a234567890123456789012345678901", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			assert.Equal(t, Matches(tt.username, UsernameRX), tt.want)
+		})
+	}
+}
+
+func TestValidator(t *testing.T) {
+	v := Validator{}
+	assert.Equal(t, v.Valid(), true)
+
+	v.CheckField(NotBlank("  "), "title", "first")
+	v.CheckField(false, "title", "second")
+	assert.Equal(t, v.Valid(), false)
+	assert.Equal(t, v.FieldErrors["title"], "first")
+
+	v = Validator{}
+	v.AddNonFieldError("oops")
+	assert.Equal(t, v.Valid(), false)
+}
+
+func TestIsPositiveInt(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"1", true},
+		{"42", true},
+		{"0", false},
+		{"-3", false},
+		{"2.5", false},
+		{"two", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, IsPositiveInt(tt.value), tt.want)
+		})
+	}
+}
+
+func TestIsHTTPURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{"https", "https://example.com/cover.png", true},
+		{"http", "http://example.com/cover.png", true},
+		{"relative", "/static/cover.png", false},
+		{"other scheme", "javascript:alert(1)", false},
+		{"no host", "https:///cover.png", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, IsHTTPURL(tt.value), tt.want)
+		})
+	}
+}
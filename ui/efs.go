@@ -0,0 +1,9 @@
+package ui
+
+import "embed"
+
+// Files holds the html templates and static assets compiled into the
+// binary.
+//
+//go:embed "html" "static"
+var Files embed.FS